@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"iter"
+	"log/slog"
+	"maps"
 	"slices"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
@@ -14,28 +18,97 @@ import (
 )
 
 func cloudfrontCmd() *cobra.Command {
-	return &cobra.Command{
+	var query cloudfrontQuery
+	cmd := &cobra.Command{
 		Use:   "cloudfront [needle]",
-		Short: "Find CloudFront distributions by domain",
-		Args:  cobra.ExactArgs(1),
+		Short: "Find CloudFront distributions by domain, certificate, WAF ACL, origin, policy or edge function",
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := query.validate(); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				query.needle = args[0]
+			}
+			query.tags = tagFiltersFromContext(cmd.Context())
 			return finder.SearchPerProfile(
 				cmd.Context(),
 				func(ctx context.Context, conf aws.Config) error {
-					return findCloudFrontDistributions(ctx, args[0], cloudfront.NewFromConfig(conf))
+					return findCloudFrontDistributions(ctx, query, cloudfront.NewFromConfig(conf))
 				})
 		},
 	}
+	cmd.Flags().StringSliceVar(
+		&query.fields,
+		"field",
+		nil,
+		fmt.Sprintf(
+			"Only match the needle against these fields (%s)",
+			strings.Join(slices.Sorted(maps.Keys(cloudfrontFields())), ", "),
+		),
+	)
+	cmd.Flags().StringVar(
+		&query.status,
+		"status",
+		"",
+		fmt.Sprintf("Only find %s or %s distributions", cloudfrontEnabled, cloudfrontDisabled),
+	)
+	cmd.Flags().StringVar(
+		&query.function,
+		"function",
+		"",
+		"Only find distributions using this CloudFront Function or Lambda@Edge function name or ARN",
+	)
+	return cmd
 }
 
-func findCloudFrontDistributions(
-	ctx context.Context, needle string, client cloudfront.ListDistributionsAPIClient,
-) error {
+const (
+	cloudfrontEnabled  = "enabled"
+	cloudfrontDisabled = "disabled"
+)
+
+type cloudfrontQuery struct {
+	needle   string
+	fields   []string
+	status   string
+	function string
+	tags     []tagFilter
+}
+
+func (q cloudfrontQuery) validate() error {
+	lookup := cloudfrontFields()
+	for _, field := range q.fields {
+		if _, ok := lookup[field]; !ok {
+			return fmt.Errorf("unknown cloudfront field %q", field)
+		}
+	}
+	if q.status != "" && q.status != cloudfrontEnabled && q.status != cloudfrontDisabled {
+		return fmt.Errorf("unknown cloudfront status %q", q.status)
+	}
+	return nil
+}
+
+func (q cloudfrontQuery) matches(dist types.DistributionSummary) bool {
+	if q.status != "" && q.status != cloudfrontStatus(dist) {
+		return false
+	}
+	if q.function != "" && !slices.ContainsFunc(
+		cloudfrontFunctionAssociations(dist),
+		func(association cloudfrontFunctionAssociation) bool {
+			return check(q.function, association.function)
+		},
+	) {
+		return false
+	}
+	return findCloudFrontDistribution(q.needle, q.fields, dist)
+}
+
+func findCloudFrontDistributions(ctx context.Context, query cloudfrontQuery, client cloudfrontLister) error {
 	pages := cloudfront.NewListDistributionsPaginator(client, nil)
 
 	seq := paginatorToSeq(ctx, pages, cloudfrontListToItems)
 	seq = filter2(func(dist types.DistributionSummary, err error) bool {
-		return err != nil || findCloudFrontDistribution(needle, dist)
+		return err != nil || query.matches(dist)
 	}, seq)
 
 	for dist, err := range seq {
@@ -43,7 +116,30 @@ func findCloudFrontDistributions(
 			return err
 		}
 
-		log.Logger(ctx).InfoContext(ctx, aws.ToString(dist.Id))
+		matched, err := cloudfrontDistributionMatchesTags(ctx, query.tags, client, dist)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		if query.function == "" {
+			log.Logger(ctx).InfoContext(ctx, aws.ToString(dist.Id))
+			continue
+		}
+		for _, association := range cloudfrontFunctionAssociations(dist) {
+			if !check(query.function, association.function) {
+				continue
+			}
+			log.Logger(ctx).InfoContext(
+				ctx,
+				aws.ToString(dist.Id),
+				slog.String("path-pattern", association.pathPattern),
+				slog.String("event-type", association.eventType),
+				slog.String("function", aws.ToString(association.function)),
+			)
+		}
 	}
 
 	return nil
@@ -53,15 +149,211 @@ func cloudfrontListToItems(r *cloudfront.ListDistributionsOutput) iter.Seq[types
 	return slices.Values(r.DistributionList.Items)
 }
 
-func findCloudFrontDistribution(needle string, dist types.DistributionSummary) bool {
-	var values = []*string{dist.DomainName}
-	if dist.Aliases != nil && dist.Aliases.Items != nil {
-		values = append(values, aws.StringSlice(dist.Aliases.Items)...)
+func findCloudFrontDistribution(needle string, fields []string, dist types.DistributionSummary) bool {
+	lookup := cloudfrontFields()
+	if len(fields) == 0 {
+		fields = slices.Collect(maps.Keys(lookup))
+	}
+
+	for _, field := range fields {
+		if check(needle, lookup[field](dist)...) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cloudfrontStatus(dist types.DistributionSummary) string {
+	if aws.ToBool(dist.Enabled) {
+		return cloudfrontEnabled
+	}
+	return cloudfrontDisabled
+}
+
+// cloudfrontFields returns the values of a distribution that can be matched, keyed by the name used with `--field`.
+func cloudfrontFields() map[string]func(types.DistributionSummary) []*string {
+	return map[string]func(types.DistributionSummary) []*string{
+		"domain": func(dist types.DistributionSummary) []*string {
+			return []*string{dist.DomainName}
+		},
+		"alias": func(dist types.DistributionSummary) []*string {
+			if dist.Aliases == nil {
+				return nil
+			}
+			return aws.StringSlice(dist.Aliases.Items)
+		},
+		"origin": func(dist types.DistributionSummary) []*string {
+			return cloudfrontOriginValues(dist, func(origin types.Origin) *string {
+				return origin.DomainName
+			})
+		},
+		"origin-path": func(dist types.DistributionSummary) []*string {
+			return cloudfrontOriginValues(dist, func(origin types.Origin) *string {
+				return origin.OriginPath
+			})
+		},
+		"origin-access": func(dist types.DistributionSummary) []*string {
+			return append(
+				cloudfrontOriginValues(dist, func(origin types.Origin) *string {
+					return origin.OriginAccessControlId
+				}),
+				cloudfrontOriginValues(dist, func(origin types.Origin) *string {
+					if origin.S3OriginConfig == nil {
+						return nil
+					}
+					return origin.S3OriginConfig.OriginAccessIdentity
+				})...,
+			)
+		},
+		"certificate": func(dist types.DistributionSummary) []*string {
+			var certificate types.ViewerCertificate
+			if dist.ViewerCertificate != nil {
+				certificate = *dist.ViewerCertificate
+			}
+			return []*string{certificate.ACMCertificateArn, certificate.IAMCertificateId}
+		},
+		"waf": func(dist types.DistributionSummary) []*string {
+			return []*string{dist.WebACLId}
+		},
+		"cache-policy": func(dist types.DistributionSummary) []*string {
+			return cloudfrontBehaviorValues(dist, func(behavior cloudfrontCacheBehavior) *string {
+				return behavior.cachePolicyID
+			})
+		},
+		"origin-request-policy": func(dist types.DistributionSummary) []*string {
+			return cloudfrontBehaviorValues(dist, func(behavior cloudfrontCacheBehavior) *string {
+				return behavior.originRequestPolicyID
+			})
+		},
+	}
+}
+
+func cloudfrontOriginValues(dist types.DistributionSummary, f func(types.Origin) *string) []*string {
+	if dist.Origins == nil {
+		return nil
+	}
+	var values []*string
+	for _, origin := range dist.Origins.Items {
+		values = append(values, f(origin))
+	}
+	return values
+}
+
+// cloudfrontCacheBehavior holds the fields shared by the default cache behavior and the others, which are separate
+// types.
+type cloudfrontCacheBehavior struct {
+	pathPattern                string
+	cachePolicyID              *string
+	originRequestPolicyID      *string
+	functionAssociations       *types.FunctionAssociations
+	lambdaFunctionAssociations *types.LambdaFunctionAssociations
+}
+
+func cloudfrontCacheBehaviors(dist types.DistributionSummary) []cloudfrontCacheBehavior {
+	var behaviors []cloudfrontCacheBehavior
+	if behavior := dist.DefaultCacheBehavior; behavior != nil {
+		behaviors = append(behaviors, cloudfrontCacheBehavior{
+			pathPattern:                cloudfrontDefaultPathPattern,
+			cachePolicyID:              behavior.CachePolicyId,
+			originRequestPolicyID:      behavior.OriginRequestPolicyId,
+			functionAssociations:       behavior.FunctionAssociations,
+			lambdaFunctionAssociations: behavior.LambdaFunctionAssociations,
+		})
+	}
+	if dist.CacheBehaviors != nil {
+		for _, behavior := range dist.CacheBehaviors.Items {
+			behaviors = append(behaviors, cloudfrontCacheBehavior{
+				pathPattern:                aws.ToString(behavior.PathPattern),
+				cachePolicyID:              behavior.CachePolicyId,
+				originRequestPolicyID:      behavior.OriginRequestPolicyId,
+				functionAssociations:       behavior.FunctionAssociations,
+				lambdaFunctionAssociations: behavior.LambdaFunctionAssociations,
+			})
+		}
+	}
+	return behaviors
+}
+
+// cloudfrontDefaultPathPattern is the path pattern of the default cache behavior, which matches every request.
+const cloudfrontDefaultPathPattern = "*"
+
+type cloudfrontFunctionAssociation struct {
+	pathPattern string
+	eventType   string
+	function    *string
+}
+
+// cloudfrontFunctionAssociations returns the CloudFront Functions and Lambda@Edge functions used by every cache
+// behavior of the distribution.
+func cloudfrontFunctionAssociations(dist types.DistributionSummary) []cloudfrontFunctionAssociation {
+	var associations []cloudfrontFunctionAssociation
+	for _, behavior := range cloudfrontCacheBehaviors(dist) {
+		if behavior.functionAssociations != nil {
+			for _, association := range behavior.functionAssociations.Items {
+				associations = append(associations, cloudfrontFunctionAssociation{
+					pathPattern: behavior.pathPattern,
+					eventType:   string(association.EventType),
+					function:    association.FunctionARN,
+				})
+			}
+		}
+		if behavior.lambdaFunctionAssociations != nil {
+			for _, association := range behavior.lambdaFunctionAssociations.Items {
+				associations = append(associations, cloudfrontFunctionAssociation{
+					pathPattern: behavior.pathPattern,
+					eventType:   string(association.EventType),
+					function:    association.LambdaFunctionARN,
+				})
+			}
+		}
+	}
+	return associations
+}
+
+func cloudfrontBehaviorValues(dist types.DistributionSummary, f func(cloudfrontCacheBehavior) *string) []*string {
+	var values []*string
+	for _, behavior := range cloudfrontCacheBehaviors(dist) {
+		values = append(values, f(behavior))
 	}
-	if dist.Origins != nil {
-		for _, name := range dist.Origins.Items {
-			values = append(values, name.DomainName)
+	return values
+}
+
+// cloudfrontDistributionMatchesTags checks the tags of the distribution, which aren't returned by ListDistributions.
+func cloudfrontDistributionMatchesTags(
+	ctx context.Context, tags []tagFilter, client cloudfrontLister, dist types.DistributionSummary,
+) (bool, error) {
+	if len(tags) == 0 {
+		return true, nil
+	}
+
+	distTags, err := cloudfrontDistributionTags(ctx, client, dist)
+	if err != nil {
+		return false, err
+	}
+	return matchesTagFilters(tags, distTags), nil
+}
+
+func cloudfrontDistributionTags(
+	ctx context.Context, client cloudfrontLister, dist types.DistributionSummary,
+) (map[string]string, error) {
+	output, err := client.ListTagsForResource(ctx, &cloudfront.ListTagsForResourceInput{Resource: dist.ARN})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags for distribution %q: %w", aws.ToString(dist.Id), err)
+	}
+
+	tags := map[string]string{}
+	if output.Tags != nil {
+		for _, tag := range output.Tags.Items {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
 		}
 	}
-	return check(needle, values...)
+	return tags, nil
+}
+
+type cloudfrontLister interface {
+	cloudfront.ListDistributionsAPIClient
+	ListTagsForResource(
+		ctx context.Context, params *cloudfront.ListTagsForResourceInput, optFns ...func(*cloudfront.Options),
+	) (*cloudfront.ListTagsForResourceOutput, error)
 }
@@ -20,11 +20,13 @@ import (
 
 func TestFindCloudFrontDistributions(t *testing.T) {
 	var tests = []struct {
+		name          string
 		distributions [][]types.DistributionSummary
-		needle        string
+		query         cloudfrontQuery
 		expected      string
 	}{
 		{
+			"domain",
 			[][]types.DistributionSummary{
 				{
 					{
@@ -41,10 +43,11 @@ func TestFindCloudFrontDistributions(t *testing.T) {
 					},
 				},
 			},
-			"domain-name",
+			cloudfrontQuery{needle: "domain-name"},
 			"found",
 		},
 		{
+			"alias",
 			[][]types.DistributionSummary{
 				{
 					{
@@ -65,10 +68,11 @@ func TestFindCloudFrontDistributions(t *testing.T) {
 					},
 				},
 			},
-			"alias",
+			cloudfrontQuery{needle: "alias"},
 			"found",
 		},
 		{
+			"origin",
 			[][]types.DistributionSummary{
 				{
 					{
@@ -94,13 +98,182 @@ func TestFindCloudFrontDistributions(t *testing.T) {
 					},
 				},
 			},
-			"origin",
+			cloudfrontQuery{needle: "origin"},
+			"found",
+		},
+		{
+			"certificate",
+			[][]types.DistributionSummary{
+				{
+					{
+						Id:                aws.String("unused"),
+						ViewerCertificate: &types.ViewerCertificate{CloudFrontDefaultCertificate: aws.Bool(true)},
+					},
+					{
+						Id: aws.String("found"),
+						ViewerCertificate: &types.ViewerCertificate{
+							ACMCertificateArn: aws.String("arn:aws:acm:us-east-1:123456789012:certificate/abc"),
+						},
+					},
+				},
+			},
+			cloudfrontQuery{needle: "certificate/abc"},
+			"found",
+		},
+		{
+			"iam certificate",
+			[][]types.DistributionSummary{
+				{
+					{
+						Id:                aws.String("found"),
+						ViewerCertificate: &types.ViewerCertificate{IAMCertificateId: aws.String("ASCACERT")},
+					},
+				},
+			},
+			cloudfrontQuery{needle: "ASCACERT", fields: []string{"certificate"}},
+			"found",
+		},
+		{
+			"no certificate",
+			[][]types.DistributionSummary{
+				{
+					{Id: aws.String("found")},
+				},
+			},
+			cloudfrontQuery{fields: []string{"certificate"}},
+			"found",
+		},
+		{
+			"waf",
+			[][]types.DistributionSummary{
+				{
+					{Id: aws.String("unused"), WebACLId: aws.String("")},
+					{
+						Id:       aws.String("found"),
+						WebACLId: aws.String("arn:aws:wafv2:us-east-1:123456789012:global/webacl/acl"),
+					},
+				},
+			},
+			cloudfrontQuery{needle: "webacl/acl"},
+			"found",
+		},
+		{
+			"origin access control",
+			[][]types.DistributionSummary{
+				{
+					{
+						Id: aws.String("found"),
+						Origins: &types.Origins{Items: []types.Origin{
+							{DomainName: aws.String("bucket.s3"), OriginAccessControlId: aws.String("E2OAC")},
+						}},
+					},
+				},
+			},
+			cloudfrontQuery{needle: "E2OAC", fields: []string{"origin-access"}},
+			"found",
+		},
+		{
+			"origin access identity",
+			[][]types.DistributionSummary{
+				{
+					{
+						Id: aws.String("found"),
+						Origins: &types.Origins{Items: []types.Origin{
+							{
+								DomainName: aws.String("bucket.s3"),
+								S3OriginConfig: &types.S3OriginConfig{
+									OriginAccessIdentity: aws.String("origin-access-identity/cloudfront/E1OAI"),
+								},
+							},
+						}},
+					},
+				},
+			},
+			cloudfrontQuery{needle: "E1OAI"},
+			"found",
+		},
+		{
+			"origin path",
+			[][]types.DistributionSummary{
+				{
+					{
+						Id: aws.String("unused"),
+						Origins: &types.Origins{Items: []types.Origin{
+							{DomainName: aws.String("static"), OriginPath: aws.String("/static")},
+						}},
+					},
+					{
+						Id: aws.String("found"),
+						Origins: &types.Origins{Items: []types.Origin{
+							{DomainName: aws.String("static"), OriginPath: aws.String("/v2/static")},
+						}},
+					},
+				},
+			},
+			cloudfrontQuery{needle: "/v2", fields: []string{"origin-path"}},
+			"found",
+		},
+		{
+			"only selected fields",
+			[][]types.DistributionSummary{
+				{
+					{Id: aws.String("unused"), DomainName: aws.String("policy.example.com")},
+					{
+						Id:                   aws.String("found"),
+						DomainName:           aws.String("example.com"),
+						DefaultCacheBehavior: &types.DefaultCacheBehavior{CachePolicyId: aws.String("policy")},
+					},
+				},
+			},
+			cloudfrontQuery{needle: "policy", fields: []string{"cache-policy"}},
+			"found",
+		},
+		{
+			"origin request policy",
+			[][]types.DistributionSummary{
+				{
+					{
+						Id:                   aws.String("unused"),
+						DefaultCacheBehavior: &types.DefaultCacheBehavior{OriginRequestPolicyId: aws.String("other")},
+					},
+					{
+						Id:                   aws.String("found"),
+						DefaultCacheBehavior: &types.DefaultCacheBehavior{OriginRequestPolicyId: aws.String("other")},
+						CacheBehaviors: &types.CacheBehaviors{Items: []types.CacheBehavior{
+							{OriginRequestPolicyId: aws.String("wanted")},
+						}},
+					},
+				},
+			},
+			cloudfrontQuery{needle: "wanted", fields: []string{"origin-request-policy"}},
+			"found",
+		},
+		{
+			"disabled",
+			[][]types.DistributionSummary{
+				{
+					{Id: aws.String("unused"), DomainName: aws.String("example.com"), Enabled: aws.Bool(true)},
+					{Id: aws.String("found"), DomainName: aws.String("example.com"), Enabled: aws.Bool(false)},
+				},
+			},
+			cloudfrontQuery{needle: "example.com", status: cloudfrontDisabled},
+			"found",
+		},
+		{
+			"enabled without needle",
+			[][]types.DistributionSummary{
+				{
+					{Id: aws.String("found"), Enabled: aws.Bool(true)},
+					{Id: aws.String("unused"), Enabled: aws.Bool(false)},
+				},
+			},
+			cloudfrontQuery{status: cloudfrontEnabled},
 			"found",
 		},
 	}
 
 	for _, test := range tests {
-		t.Run(test.needle, func(t *testing.T) {
+		t.Run(test.name, func(t *testing.T) {
 			var buf bytes.Buffer
 
 			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
@@ -110,17 +283,140 @@ func TestFindCloudFrontDistributions(t *testing.T) {
 				}),
 			}))
 
-			err := findCloudFrontDistributions(ctx, test.needle, &distributions{test.distributions})
+			err := findCloudFrontDistributions(ctx, test.query, &distributions{distributions: test.distributions})
 			require.NoError(t, err)
 			assert.Equal(t, fmt.Sprintf("level=INFO msg=%s\n", test.expected), buf.String())
 		})
 	}
 }
 
-var _ cloudfront.ListDistributionsAPIClient = &distributions{}
+func TestFindCloudFrontDistributions_Tags(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	env, err := parseTagFilter("env=prod")
+	require.NoError(t, err)
+
+	query := cloudfrontQuery{needle: "example.com", tags: []tagFilter{env}}
+	err = findCloudFrontDistributions(ctx, query, &distributions{
+		distributions: [][]types.DistributionSummary{
+			{
+				{
+					Id:         aws.String("unused"),
+					ARN:        aws.String("arn:dev"),
+					DomainName: aws.String("dev.example.com"),
+				},
+				{
+					Id:         aws.String("found"),
+					ARN:        aws.String("arn:prod"),
+					DomainName: aws.String("prod.example.com"),
+				},
+			},
+		},
+		tags: map[string][]types.Tag{
+			"arn:dev": {
+				{
+					Key:   aws.String("env"),
+					Value: aws.String("dev"),
+				},
+			},
+			"arn:prod": {
+				{
+					Key:   aws.String("env"),
+					Value: aws.String("prod"),
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "level=INFO msg=found\n", buf.String())
+}
+
+func TestFindCloudFrontDistributions_Function(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	err := findCloudFrontDistributions(ctx, cloudfrontQuery{function: "rewrite"}, &distributions{
+		distributions: [][]types.DistributionSummary{
+			{
+				{
+					Id: aws.String("unused"),
+					DefaultCacheBehavior: &types.DefaultCacheBehavior{
+						FunctionAssociations: &types.FunctionAssociations{Items: []types.FunctionAssociation{
+							{
+								EventType:   types.EventTypeViewerRequest,
+								FunctionARN: aws.String("arn:aws:cloudfront::123456789012:function/redirect"),
+							},
+						}},
+					},
+				},
+				{
+					Id: aws.String("found"),
+					DefaultCacheBehavior: &types.DefaultCacheBehavior{
+						FunctionAssociations: &types.FunctionAssociations{Items: []types.FunctionAssociation{
+							{
+								EventType:   types.EventTypeViewerRequest,
+								FunctionARN: aws.String("arn:aws:cloudfront::123456789012:function/rewrite"),
+							},
+							{
+								EventType:   types.EventTypeViewerResponse,
+								FunctionARN: aws.String("arn:aws:cloudfront::123456789012:function/headers"),
+							},
+						}},
+					},
+					CacheBehaviors: &types.CacheBehaviors{Items: []types.CacheBehavior{
+						{
+							PathPattern: aws.String("/api/*"),
+							LambdaFunctionAssociations: &types.LambdaFunctionAssociations{
+								Items: []types.LambdaFunctionAssociation{
+									{
+										EventType: types.EventTypeOriginRequest,
+										LambdaFunctionARN: aws.String(
+											"arn:aws:lambda:us-east-1:123456789012:function:rewrite:3",
+										),
+									},
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"level=INFO msg=found path-pattern=* event-type=viewer-request "+
+			"function=arn:aws:cloudfront::123456789012:function/rewrite\n"+
+			"level=INFO msg=found path-pattern=/api/* event-type=origin-request "+
+			"function=arn:aws:lambda:us-east-1:123456789012:function:rewrite:3\n",
+		buf.String(),
+	)
+}
+
+func TestCloudFrontQuery_Validate(t *testing.T) {
+	require.NoError(t, cloudfrontQuery{fields: []string{"waf", "origin-path"}, status: cloudfrontEnabled}.validate())
+	require.EqualError(t, cloudfrontQuery{fields: []string{"wat"}}.validate(), `unknown cloudfront field "wat"`)
+	require.EqualError(t, cloudfrontQuery{status: "deployed"}.validate(), `unknown cloudfront status "deployed"`)
+}
+
+var _ cloudfrontLister = &distributions{}
 
 type distributions struct {
 	distributions [][]types.DistributionSummary
+	tags          map[string][]types.Tag
 }
 
 func (d *distributions) ListDistributions(
@@ -145,3 +441,15 @@ func (d *distributions) ListDistributions(
 		},
 	}, nil
 }
+
+func (d *distributions) ListTagsForResource(
+	ctx context.Context, params *cloudfront.ListTagsForResourceInput, _ ...func(*cloudfront.Options),
+) (*cloudfront.ListTagsForResourceOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+
+	return &cloudfront.ListTagsForResourceOutput{
+		Tags: &types.Tags{Items: d.tags[aws.ToString(params.Resource)]},
+	}, nil
+}
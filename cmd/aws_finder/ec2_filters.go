@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+const (
+	shortEc2IDLength = 8
+	longEc2IDLength  = 17
+)
+
+// ec2FilterTranslator turns a needle into a server-side EC2 filter, returning false if the needle isn't exact enough
+// to be pushed down and so has to be matched client-side.
+type ec2FilterTranslator func(needle string) (types.Filter, bool)
+
+// ec2NeedleFilters returns the filters from the first translator that accepts the needle. EC2 requires every filter to
+// match rather than any of them, so the filters replace matching the needle client-side and must only be used when
+// the needle can't match any other field.
+func ec2NeedleFilters(needle string, translators ...ec2FilterTranslator) []types.Filter {
+	for _, translate := range translators {
+		if filter, ok := translate(needle); ok {
+			return []types.Filter{filter}
+		}
+	}
+	return nil
+}
+
+// ec2IDFilter filters on `name` if the needle is a complete EC2 resource ID with the given prefix, such as
+// `i-0123456789abcdef0` for the prefix `i`.
+func ec2IDFilter(name, prefix string) ec2FilterTranslator {
+	return func(needle string) (types.Filter, bool) {
+		if !isEc2ID(prefix, needle) {
+			return types.Filter{}, false
+		}
+		return types.Filter{Name: aws.String(name), Values: []string{needle}}, true
+	}
+}
+
+// ec2CidrFilter filters on `name` if the needle is a complete IPv4 CIDR block.
+func ec2CidrFilter(name string) ec2FilterTranslator {
+	return func(needle string) (types.Filter, bool) {
+		prefix, err := netip.ParsePrefix(needle)
+		if err != nil || !prefix.Addr().Is4() {
+			return types.Filter{}, false
+		}
+		return types.Filter{Name: aws.String(name), Values: []string{needle}}, true
+	}
+}
+
+// ec2TagFilter filters on a tag if the needle is of the form `key=value`.
+func ec2TagFilter(needle string) (types.Filter, bool) {
+	key, value, ok := strings.Cut(needle, "=")
+	if !ok || key == "" || value == "" {
+		return types.Filter{}, false
+	}
+	return types.Filter{Name: aws.String("tag:" + key), Values: []string{value}}, true
+}
+
+// ec2TagNeedleMatches checks the tags client-side in the same way as ec2TagFilter, for when the needle can't be pushed
+// down.
+func ec2TagNeedleMatches(needle string, tags []types.Tag) bool {
+	key, value, ok := strings.Cut(needle, "=")
+	if !ok || key == "" || value == "" {
+		return false
+	}
+	return slices.ContainsFunc(tags, func(tag types.Tag) bool {
+		return aws.ToString(tag.Key) == key && aws.ToString(tag.Value) == value
+	})
+}
+
+// ec2EndpointServiceNameFilter filters on the service name if the needle is the complete name of a PrivateLink
+// service, such as `com.amazonaws.vpce.eu-west-1.vpce-svc-0123456789abcdef0`. AWS service names aren't pushed down as
+// one can be part of another, such as `s3` and `s3express`.
+func ec2EndpointServiceNameFilter(needle string) (types.Filter, bool) {
+	rest, ok := strings.CutPrefix(needle, "com.amazonaws.vpce.")
+	if !ok {
+		return types.Filter{}, false
+	}
+	region, id, ok := strings.Cut(rest, ".")
+	if !ok || region == "" || !isEc2ID("vpce-svc", id) {
+		return types.Filter{}, false
+	}
+	return types.Filter{Name: aws.String("service-name"), Values: []string{needle}}, true
+}
+
+func isEc2ID(prefix, s string) bool {
+	id, ok := strings.CutPrefix(s, prefix+"-")
+	if !ok || (len(id) != shortEc2IDLength && len(id) != longEc2IDLength) {
+		return false
+	}
+	for _, c := range id {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
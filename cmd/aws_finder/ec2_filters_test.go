@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEc2NeedleFilters(t *testing.T) {
+	var tests = []struct {
+		needle   string
+		expected []types.Filter
+	}{
+		{"i-01234567", []types.Filter{{Name: aws.String("id"), Values: []string{"i-01234567"}}}},
+		{"i-0123456789abcdef0", []types.Filter{{Name: aws.String("id"), Values: []string{"i-0123456789abcdef0"}}}},
+		{"i-0123", nil},
+		{"i-0123456789ABCDEF0", nil},
+		{"10.0.0.0/8", []types.Filter{{Name: aws.String("cidr"), Values: []string{"10.0.0.0/8"}}}},
+		{"10.0.0.", nil},
+		{"2001:db8::/32", nil},
+		{"team=payments", []types.Filter{{Name: aws.String("tag:team"), Values: []string{"payments"}}}},
+		{"team=", nil},
+		{
+			"com.amazonaws.vpce.eu-west-1.vpce-svc-0123456789abcdef0",
+			[]types.Filter{{
+				Name:   aws.String("service-name"),
+				Values: []string{"com.amazonaws.vpce.eu-west-1.vpce-svc-0123456789abcdef0"},
+			}},
+		},
+		{"com.amazonaws.eu-west-1.s3", nil},
+		{"com.amazonaws.vpce.eu-west-1.vpce-svc-0123", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.needle, func(t *testing.T) {
+			assert.Equal(t, test.expected, ec2NeedleFilters(
+				test.needle, ec2IDFilter("id", "i"), ec2CidrFilter("cidr"), ec2TagFilter, ec2EndpointServiceNameFilter,
+			))
+		})
+	}
+}
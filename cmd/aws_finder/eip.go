@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+	"github.com/wjam/aws_finder/internal/finder"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func eipCmd() *cobra.Command {
+	var unassociated bool
+	cmd := &cobra.Command{
+		Use:   "eip [needle]",
+		Short: "Find an Elastic IP by address, allocation, association or tag",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var needle string
+			if len(args) == 1 {
+				needle = args[0]
+			}
+			tags := tagFiltersFromContext(cmd.Context())
+			return finder.SearchPerRegion(
+				cmd.Context(),
+				func(ctx context.Context, conf aws.Config) error {
+					return findElasticIPs(ctx, needle, unassociated, tags, ec2.NewFromConfig(conf))
+				})
+		},
+	}
+	cmd.Flags().BoolVar(&unassociated, "unassociated", false, "Only find addresses not associated with anything")
+	return cmd
+}
+
+func findElasticIPs(
+	ctx context.Context, needle string, unassociated bool, tags []tagFilter, client addressLister,
+) error {
+	addresses, err := client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{
+		Filters: ec2TagFilters(tags),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses.Addresses {
+		if unassociated && isAddressAssociated(address) {
+			continue
+		}
+		if !findElasticIP(needle, address) {
+			continue
+		}
+
+		log.Logger(ctx).InfoContext(ctx, aws.ToString(address.PublicIp), addressAttrs(address)...)
+	}
+
+	return nil
+}
+
+func findElasticIP(needle string, address types.Address) bool {
+	if check(
+		needle,
+		address.PublicIp,
+		address.AllocationId,
+		address.AssociationId,
+		address.NetworkBorderGroup,
+	) {
+		return true
+	}
+	return checkEc2Tags(needle, address.Tags)
+}
+
+func isAddressAssociated(address types.Address) bool {
+	return address.AssociationId != nil || address.InstanceId != nil || address.NetworkInterfaceId != nil
+}
+
+func addressAttrs(address types.Address) []any {
+	attrs := []any{slog.String("allocation", aws.ToString(address.AllocationId))}
+	if !isAddressAssociated(address) {
+		return append(attrs, slog.Bool("unassociated", true))
+	}
+	if address.InstanceId != nil {
+		attrs = append(attrs, slog.String("instance", aws.ToString(address.InstanceId)))
+	}
+	if address.NetworkInterfaceId != nil {
+		attrs = append(attrs, slog.String("eni", aws.ToString(address.NetworkInterfaceId)))
+	}
+	return attrs
+}
+
+func checkEc2Tags(needle string, tags []types.Tag) bool {
+	for _, tag := range tags {
+		if check(needle, tag.Key, tag.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+type addressLister interface {
+	DescribeAddresses(
+		ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options),
+	) (*ec2.DescribeAddressesOutput, error)
+}
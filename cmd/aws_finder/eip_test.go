@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func TestFindElasticIPs(t *testing.T) {
+	var tests = []struct {
+		name         string
+		needle       string
+		unassociated bool
+		expected     string
+	}{
+		{
+			"public-ip",
+			"10.0.0.2",
+			false,
+			"level=INFO msg=10.0.0.2 allocation=eipalloc-2 instance=i-1234\n",
+		},
+		{
+			"allocation",
+			"eipalloc-3",
+			false,
+			"level=INFO msg=10.0.0.3 allocation=eipalloc-3 eni=eni-5678\n",
+		},
+		{
+			"association",
+			"eipassoc-2",
+			false,
+			"level=INFO msg=10.0.0.2 allocation=eipalloc-2 instance=i-1234\n",
+		},
+		{
+			"network-border-group",
+			"eu-west-2",
+			false,
+			"level=INFO msg=10.0.0.3 allocation=eipalloc-3 eni=eni-5678\n",
+		},
+		{
+			"tag",
+			"team-a",
+			false,
+			"level=INFO msg=10.0.0.1 allocation=eipalloc-1 unassociated=true\n",
+		},
+		{
+			"unassociated",
+			"",
+			true,
+			"level=INFO msg=10.0.0.1 allocation=eipalloc-1 unassociated=true\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			require.NoError(t, findElasticIPs(ctx, test.needle, test.unassociated, nil, &addresses{
+				addresses: []types.Address{
+					{
+						PublicIp:           aws.String("10.0.0.1"),
+						AllocationId:       aws.String("eipalloc-1"),
+						NetworkBorderGroup: aws.String("eu-west-1"),
+						Tags: []types.Tag{
+							{
+								Key:   aws.String("team"),
+								Value: aws.String("team-a"),
+							},
+						},
+					},
+					{
+						PublicIp:           aws.String("10.0.0.2"),
+						AllocationId:       aws.String("eipalloc-2"),
+						AssociationId:      aws.String("eipassoc-2"),
+						InstanceId:         aws.String("i-1234"),
+						NetworkBorderGroup: aws.String("eu-west-1"),
+					},
+					{
+						PublicIp:           aws.String("10.0.0.3"),
+						AllocationId:       aws.String("eipalloc-3"),
+						AssociationId:      aws.String("eipassoc-3"),
+						NetworkInterfaceId: aws.String("eni-5678"),
+						NetworkBorderGroup: aws.String("eu-west-2"),
+					},
+				},
+			}))
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+var _ addressLister = &addresses{}
+
+type addresses struct {
+	addresses []types.Address
+}
+
+func (a *addresses) DescribeAddresses(
+	ctx context.Context, _ *ec2.DescribeAddressesInput, _ ...func(*ec2.Options),
+) (*ec2.DescribeAddressesOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+
+	return &ec2.DescribeAddressesOutput{
+		Addresses: a.addresses,
+	}, nil
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 )
@@ -24,3 +26,68 @@ func (l *logLevelFlag) Set(s string) error {
 func (l *logLevelFlag) Type() string {
 	return fmt.Sprintf("%s|%s|%s|%s", slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError)
 }
+
+var _ pflag.Value = &tagFiltersFlag{}
+
+type tagFiltersFlag struct {
+	filters []tagFilter
+}
+
+func (t *tagFiltersFlag) String() string {
+	values := make([]string, 0, len(t.filters))
+	for _, filter := range t.filters {
+		values = append(values, filter.String())
+	}
+	return strings.Join(values, ",")
+}
+
+func (t *tagFiltersFlag) Set(s string) error {
+	filter, err := parseTagFilter(s)
+	if err != nil {
+		return err
+	}
+	t.filters = append(t.filters, filter)
+	return nil
+}
+
+func (t *tagFiltersFlag) Type() string {
+	return "key[=value]"
+}
+
+var _ pflag.Value = &timeFlag{}
+
+// timeFlag is either a time or a duration before now, such as 2h for two hours ago.
+type timeFlag struct {
+	value string
+	time  time.Time
+}
+
+func (t *timeFlag) String() string {
+	return t.value
+}
+
+func (t *timeFlag) Set(s string) error {
+	parsed, err := parseTimeFlag(s, time.Now())
+	if err != nil {
+		return err
+	}
+	t.value = s
+	t.time = parsed
+	return nil
+}
+
+func (t *timeFlag) Type() string {
+	return "duration|time"
+}
+
+func parseTimeFlag(s string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a duration nor a time, such as 2h or %s", s, time.RFC3339)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var tests = []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2h", time.Date(2024, 1, 2, 1, 4, 5, 0, time.UTC)},
+		{"90m", time.Date(2024, 1, 2, 1, 34, 5, 0, time.UTC)},
+		{"2023-12-31T23:00:00Z", time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC)},
+		{"2023-12-31 23:00:00", time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC)},
+		{"2023-12-31", time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			parsed, err := parseTimeFlag(test.value, now)
+			require.NoError(t, err)
+			assert.True(t, test.expected.Equal(parsed), "expected %s, got %s", test.expected, parsed)
+		})
+	}
+}
+
+func TestParseTimeFlag_Invalid(t *testing.T) {
+	_, err := parseTimeFlag("yesterday", time.Now())
+	require.EqualError(t, err, `"yesterday" is neither a duration nor a time, such as 2h or 2006-01-02T15:04:05Z07:00`)
+}
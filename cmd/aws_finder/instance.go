@@ -2,7 +2,10 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"iter"
+	"log/slog"
+	"maps"
 	"slices"
 	"strings"
 
@@ -15,26 +18,136 @@ import (
 )
 
 func instanceCmd() *cobra.Command {
-	return &cobra.Command{
+	var query instanceQuery
+	cmd := &cobra.Command{
 		Use:   "instance [needle]",
-		Short: "Find an instance by type, AMI or ip address",
+		Short: "Find an instance by id, name, type, AMI, DNS name or ip address",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := query.validate(); err != nil {
+				return err
+			}
+			query.needle = args[0]
+			query.tags = tagFiltersFromContext(cmd.Context())
 			return finder.SearchPerRegion(
 				cmd.Context(),
 				func(ctx context.Context, conf aws.Config) error {
-					return findInstances(ctx, args[0], ec2.NewFromConfig(conf))
+					return findInstances(ctx, query, ec2.NewFromConfig(conf))
 				})
 		},
 	}
+	cmd.Flags().StringSliceVar(
+		&query.fields,
+		"field",
+		nil,
+		fmt.Sprintf(
+			"Only match the needle against these fields (%s)",
+			strings.Join(slices.Sorted(maps.Keys(instanceFields())), ", "),
+		),
+	)
+	cmd.Flags().StringSliceVar(
+		&query.states,
+		"state",
+		nil,
+		fmt.Sprintf(
+			"Only find instances in these states (%s)",
+			strings.Join(enumStrings(types.InstanceStateName("").Values()), ", "),
+		),
+	)
+	cmd.Flags().StringSliceVar(
+		&query.lifecycles,
+		"lifecycle",
+		nil,
+		fmt.Sprintf("Only find instances with these lifecycles (%s)", strings.Join(instanceLifecycles(), ", ")),
+	)
+	return cmd
+}
+
+type instanceQuery struct {
+	needle     string
+	fields     []string
+	states     []string
+	lifecycles []string
+	tags       []tagFilter
+}
+
+func (q instanceQuery) validate() error {
+	lookup := instanceFields()
+	for _, field := range q.fields {
+		if _, ok := lookup[field]; !ok {
+			return fmt.Errorf("unknown instance field %q", field)
+		}
+	}
+	states := enumStrings(types.InstanceStateName("").Values())
+	for _, state := range q.states {
+		if !slices.Contains(states, state) {
+			return fmt.Errorf("unknown instance state %q", state)
+		}
+	}
+	lifecycles := instanceLifecycles()
+	for _, lifecycle := range q.lifecycles {
+		if !slices.Contains(lifecycles, lifecycle) {
+			return fmt.Errorf("unknown instance lifecycle %q", lifecycle)
+		}
+	}
+	return nil
+}
+
+// filters returns the parts of the query that DescribeInstances can apply server-side.
+func (q instanceQuery) filters() []types.Filter {
+	var filters []types.Filter
+	if len(q.states) != 0 {
+		filters = append(filters, types.Filter{Name: aws.String("instance-state-name"), Values: q.states})
+	}
+	// On-demand instances don't have a lifecycle, so can only be filtered client-side
+	if len(q.lifecycles) != 0 && !slices.Contains(q.lifecycles, onDemandLifecycle) {
+		filters = append(filters, types.Filter{Name: aws.String("instance-lifecycle"), Values: q.lifecycles})
+	}
+	return append(filters, ec2TagFilters(q.tags)...)
+}
+
+// needleFilters returns the server-side filters equivalent to the needle, if it's exact enough to be pushed down. It's
+// only pushed down when matched against a single field, as filtering on one field would otherwise stop the needle
+// matching the others.
+func (q instanceQuery) needleFilters() []types.Filter {
+	translators := map[string]ec2FilterTranslator{
+		"id":     ec2IDFilter("instance-id", "i"),
+		"image":  ec2IDFilter("image-id", "ami"),
+		"subnet": ec2IDFilter("subnet-id", "subnet"),
+		"tag":    ec2TagFilter,
+		"vpc":    ec2IDFilter("vpc-id", "vpc"),
+	}
+	if len(q.fields) != 1 {
+		return nil
+	}
+	translator, ok := translators[q.fields[0]]
+	if !ok {
+		return nil
+	}
+	return ec2NeedleFilters(q.needle, translator)
 }
 
-func findInstances(ctx context.Context, needle string, client ec2.DescribeInstancesAPIClient) error {
-	pages := ec2.NewDescribeInstancesPaginator(client, nil)
+func (q instanceQuery) matchesFilters(instance types.Instance) bool {
+	if len(q.states) != 0 &&
+		(instance.State == nil || !slices.Contains(q.states, string(instance.State.Name))) {
+		return false
+	}
+	return len(q.lifecycles) == 0 || slices.Contains(q.lifecycles, instanceLifecycle(instance))
+}
+
+func findInstances(ctx context.Context, query instanceQuery, client ec2.DescribeInstancesAPIClient) error {
+	needleFilters := query.needleFilters()
+	pages := ec2.NewDescribeInstancesPaginator(client, &ec2.DescribeInstancesInput{
+		Filters: append(query.filters(), needleFilters...),
+	})
 
 	seq := paginatorToSeq(ctx, pages, ec2DescribeInstancesToInstances)
 	seq = filter2(func(instance types.Instance, err error) bool {
-		return err != nil || findInstance(needle, instance)
+		if err != nil {
+			return true
+		}
+		return query.matchesFilters(instance) &&
+			(len(needleFilters) != 0 || findInstance(query.needle, query.fields, instance))
 	}, seq)
 
 	for instance, err := range seq {
@@ -42,7 +155,14 @@ func findInstances(ctx context.Context, needle string, client ec2.DescribeInstan
 			return err
 		}
 
-		log.Logger(ctx).InfoContext(ctx, aws.ToString(instance.InstanceId))
+		var attrs []any
+		if instance.State != nil {
+			attrs = append(attrs, slog.String("state", string(instance.State.Name)))
+		}
+		if instance.LaunchTime != nil {
+			attrs = append(attrs, slog.Time("launched", aws.ToTime(instance.LaunchTime)))
+		}
+		log.Logger(ctx).InfoContext(ctx, aws.ToString(instance.InstanceId), attrs...)
 	}
 
 	return nil
@@ -56,28 +176,111 @@ func ec2DescribeInstancesToInstances(d *ec2.DescribeInstancesOutput) iter.Seq[ty
 	return concat(ret...)
 }
 
-func findInstance(needle string, instance types.Instance) bool {
-	if check(needle, instance.ImageId, aws.String(string(instance.InstanceType))) {
-		return true
+func findInstance(needle string, fields []string, instance types.Instance) bool {
+	lookup := instanceFields()
+	if len(fields) == 0 {
+		fields = slices.Collect(maps.Keys(lookup))
+	}
+
+	for _, field := range fields {
+		if check(needle, lookup[field](instance)...) {
+			return true
+		}
+		if field == "tag" && ec2TagNeedleMatches(needle, instance.Tags) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const onDemandLifecycle = "on-demand"
+
+func instanceLifecycle(instance types.Instance) string {
+	if instance.InstanceLifecycle == "" {
+		return onDemandLifecycle
+	}
+	return string(instance.InstanceLifecycle)
+}
+
+func instanceLifecycles() []string {
+	return append([]string{onDemandLifecycle}, enumStrings(types.InstanceLifecycleType("").Values())...)
+}
+
+func enumStrings[T ~string](values []T) []string {
+	ret := make([]string, 0, len(values))
+	for _, v := range values {
+		ret = append(ret, string(v))
+	}
+	return ret
+}
+
+// instanceFields returns the values of an instance that can be matched, keyed by the name used with `--field`.
+func instanceFields() map[string]func(types.Instance) []*string {
+	return map[string]func(types.Instance) []*string{
+		"id": func(instance types.Instance) []*string {
+			return []*string{instance.InstanceId}
+		},
+		"image": func(instance types.Instance) []*string {
+			return []*string{instance.ImageId}
+		},
+		"type": func(instance types.Instance) []*string {
+			return []*string{aws.String(string(instance.InstanceType))}
+		},
+		"ip":  instanceIPAddresses,
+		"dns": instanceDNSNames,
+		// Only tag values are matched, as a key would match every instance with the tag. A needle of the form
+		// `key=value` is matched against the whole tag by findInstance.
+		"tag": func(instance types.Instance) []*string {
+			values := make([]*string, 0, len(instance.Tags))
+			for _, tag := range instance.Tags {
+				values = append(values, tag.Value)
+			}
+			return values
+		},
+		"key-name": func(instance types.Instance) []*string {
+			return []*string{instance.KeyName}
+		},
+		"iam-profile": func(instance types.Instance) []*string {
+			if instance.IamInstanceProfile == nil {
+				return nil
+			}
+			return []*string{instance.IamInstanceProfile.Arn, instance.IamInstanceProfile.Id}
+		},
+		"subnet": func(instance types.Instance) []*string {
+			return []*string{instance.SubnetId}
+		},
+		"vpc": func(instance types.Instance) []*string {
+			return []*string{instance.VpcId}
+		},
 	}
+}
 
+func instanceIPAddresses(instance types.Instance) []*string {
+	values := []*string{instance.PrivateIpAddress, instance.PublicIpAddress, instance.Ipv6Address}
 	for _, network := range instance.NetworkInterfaces {
 		for _, ip := range network.PrivateIpAddresses {
-			if check(needle, ip.PrivateIpAddress) {
-				return true
-			}
+			values = append(values, ip.PrivateIpAddress)
 		}
 		for _, ip := range network.Ipv6Addresses {
-			if check(needle, ip.Ipv6Address) {
-				return true
-			}
+			values = append(values, ip.Ipv6Address)
 		}
-		if network.Association != nil && check(needle, network.Association.PublicIp) {
-			return true
+		if network.Association != nil {
+			values = append(values, network.Association.PublicIp)
 		}
 	}
+	return values
+}
 
-	return false
+func instanceDNSNames(instance types.Instance) []*string {
+	values := []*string{instance.PrivateDnsName, instance.PublicDnsName}
+	for _, network := range instance.NetworkInterfaces {
+		values = append(values, network.PrivateDnsName)
+		if network.Association != nil {
+			values = append(values, network.Association.PublicDnsName)
+		}
+	}
+	return values
 }
 
 func check(needle string, haystack ...*string) bool {
@@ -9,6 +9,7 @@ import (
 	"log/slog"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -177,6 +178,94 @@ func TestFindInstance(t *testing.T) {
 			"image-id",
 			"found",
 		},
+		{
+			[][]types.Reservation{
+				{
+					{
+						Instances: []types.Instance{
+							{
+								InstanceId:     aws.String("not reported"),
+								PrivateDnsName: aws.String("ip-10-1-2-4.eu-west-1.compute.internal"),
+							},
+							{
+								InstanceId:     aws.String("found"),
+								PrivateDnsName: aws.String("ip-10-1-2-3.eu-west-1.compute.internal"),
+							},
+						},
+					},
+				},
+			},
+			"ip-10-1-2-3",
+			"found",
+		},
+		{
+			[][]types.Reservation{
+				{
+					{
+						Instances: []types.Instance{
+							{
+								InstanceId: aws.String("not reported"),
+								Tags: []types.Tag{
+									{
+										Key:   aws.String("payments-owner"),
+										Value: aws.String("web-server"),
+									},
+								},
+							},
+							{
+								InstanceId: aws.String("found"),
+								Tags: []types.Tag{
+									{
+										Key:   aws.String("Name"),
+										Value: aws.String("payments-api"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"payments",
+			"found",
+		},
+		{
+			[][]types.Reservation{
+				{
+					{
+						Instances: []types.Instance{
+							{
+								InstanceId: aws.String("i-0123456789abcdef0"),
+							},
+							{
+								InstanceId: aws.String("i-0fedcba9876543210"),
+							},
+						},
+					},
+				},
+			},
+			"i-0fedcba",
+			"i-0fedcba9876543210",
+		},
+		{
+			[][]types.Reservation{
+				{
+					{
+						Instances: []types.Instance{
+							{
+								InstanceId: aws.String("not reported"),
+								Tags:       []types.Tag{{Key: aws.String("team"), Value: aws.String("web")}},
+							},
+							{
+								InstanceId: aws.String("found"),
+								Tags:       []types.Tag{{Key: aws.String("team"), Value: aws.String("payments")}},
+							},
+						},
+					},
+				},
+			},
+			"team=payments",
+			"found",
+		},
 	}
 
 	for _, test := range tests {
@@ -190,24 +279,150 @@ func TestFindInstance(t *testing.T) {
 				}),
 			}))
 
-			require.NoError(t, findInstances(ctx, test.needle, &instances{test.reservations}))
+			err := findInstances(ctx, instanceQuery{needle: test.needle}, &instances{reservations: test.reservations})
+			require.NoError(t, err)
 			assert.Equal(t, fmt.Sprintf("level=INFO msg=%s\n", test.expected), buf.String())
 		})
 	}
 }
 
+func TestFindInstance_Fields(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	require.NoError(t, findInstances(ctx, instanceQuery{needle: "subnet-1", fields: []string{"subnet"}}, &instances{
+		reservations: [][]types.Reservation{
+			{
+				{
+					Instances: []types.Instance{
+						{
+							InstanceId: aws.String("not reported"),
+							SubnetId:   aws.String("subnet-2"),
+							Tags: []types.Tag{
+								{
+									Key:   aws.String("subnet"),
+									Value: aws.String("subnet-1"),
+								},
+							},
+						},
+						{
+							InstanceId: aws.String("found"),
+							SubnetId:   aws.String("subnet-1"),
+						},
+					},
+				},
+			},
+		},
+	}))
+	assert.Equal(t, "level=INFO msg=found\n", buf.String())
+}
+
+func TestFindInstance_StateAndLifecycle(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	client := &instances{
+		reservations: [][]types.Reservation{
+			{
+				{
+					Instances: []types.Instance{
+						{
+							InstanceId:        aws.String("wrong lifecycle"),
+							InstanceLifecycle: types.InstanceLifecycleTypeSpot,
+							State:             &types.InstanceState{Name: types.InstanceStateNameRunning},
+						},
+						{
+							InstanceId: aws.String("wrong state"),
+							State:      &types.InstanceState{Name: types.InstanceStateNameTerminated},
+						},
+						{
+							InstanceId: aws.String("found"),
+							State:      &types.InstanceState{Name: types.InstanceStateNameRunning},
+							LaunchTime: aws.Time(time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)),
+						},
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, findInstances(ctx, instanceQuery{
+		needle:     "",
+		states:     []string{"running", "stopped"},
+		lifecycles: []string{"on-demand"},
+	}, client))
+
+	assert.Equal(t, "level=INFO msg=found state=running launched=2024-03-01T12:00:00.000Z\n", buf.String())
+	assert.Equal(t, []types.Filter{
+		{Name: aws.String("instance-state-name"), Values: []string{"running", "stopped"}},
+	}, client.filters)
+}
+
+func TestInstanceQuery_NeedleFilters(t *testing.T) {
+	assert.Empty(t, instanceQuery{needle: "i-0123", fields: []string{"id"}}.needleFilters())
+	assert.Equal(t, []types.Filter{
+		{Name: aws.String("instance-id"), Values: []string{"i-0123456789abcdef0"}},
+	}, instanceQuery{needle: "i-0123456789abcdef0", fields: []string{"id"}}.needleFilters())
+	assert.Equal(t, []types.Filter{
+		{Name: aws.String("tag:Name"), Values: []string{"web"}},
+	}, instanceQuery{needle: "Name=web", fields: []string{"tag"}}.needleFilters())
+	assert.Empty(t, instanceQuery{needle: "i-0123456789abcdef0"}.needleFilters())
+	assert.Empty(t, instanceQuery{needle: "i-0123456789abcdef0", fields: []string{"id", "dns"}}.needleFilters())
+	assert.Empty(t, instanceQuery{needle: "subnet-01234567", fields: []string{"ip"}}.needleFilters())
+}
+
+func TestInstanceQuery_Filters(t *testing.T) {
+	assert.Empty(t, instanceQuery{}.filters())
+	assert.Equal(t, []types.Filter{
+		{Name: aws.String("instance-state-name"), Values: []string{"stopped"}},
+		{Name: aws.String("instance-lifecycle"), Values: []string{"spot", "scheduled"}},
+	}, instanceQuery{states: []string{"stopped"}, lifecycles: []string{"spot", "scheduled"}}.filters())
+	assert.Equal(t, []types.Filter{
+		{Name: aws.String("instance-lifecycle"), Values: []string{"spot"}},
+		{Name: aws.String("tag-key"), Values: []string{"owner"}},
+	}, instanceQuery{lifecycles: []string{"spot"}, tags: []tagFilter{{key: "owner"}}}.filters())
+}
+
+func TestInstanceQuery_Validate(t *testing.T) {
+	require.NoError(t, instanceQuery{
+		fields:     []string{"dns", "tag"},
+		states:     []string{"running"},
+		lifecycles: []string{"on-demand", "spot"},
+	}.validate())
+	require.EqualError(
+		t, instanceQuery{fields: []string{"dns", "unknown"}}.validate(), `unknown instance field "unknown"`,
+	)
+	require.EqualError(t, instanceQuery{states: []string{"asleep"}}.validate(), `unknown instance state "asleep"`)
+	require.EqualError(
+		t, instanceQuery{lifecycles: []string{"spotty"}}.validate(), `unknown instance lifecycle "spotty"`,
+	)
+}
+
 var _ ec2.DescribeInstancesAPIClient = &instances{}
 
 type instances struct {
 	reservations [][]types.Reservation
+	filters      []types.Filter
 }
 
 func (i *instances) DescribeInstances(
-	ctx context.Context, _ *ec2.DescribeInstancesInput, _ ...func(*ec2.Options),
+	ctx context.Context, input *ec2.DescribeInstancesInput, _ ...func(*ec2.Options),
 ) (*ec2.DescribeInstancesOutput, error) {
 	if ctx == nil {
 		return nil, errors.New("missing context")
 	}
+	i.filters = input.Filters
 
 	var value []types.Reservation
 	value, i.reservations = i.reservations[0], i.reservations[1:]
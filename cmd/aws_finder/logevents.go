@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/spf13/cobra"
+	"github.com/wjam/aws_finder/internal/finder"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func logEventsCmd() *cobra.Command {
+	query := logEventsQuery{concurrency: defaultLogGroupConcurrency}
+	var since, until timeFlag
+	cmd := &cobra.Command{
+		Use:   "log_events <logGroupPrefix> <pattern>",
+		Short: "Find CloudWatch log events matching a filter pattern",
+		Long: `Find CloudWatch log events matching a filter pattern, in every log group starting with the prefix.
+
+The pattern uses the CloudWatch Logs filter pattern syntax, so terms containing anything other than letters and
+numbers need to be quoted, such as '"8f6c1b2a-0d3e-4f5a-9b7c-1d2e3f4a5b6c"'.`,
+		Args: cobra.ExactArgs(2), //nolint:mnd // group prefix and pattern
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateConcurrency(query.concurrency); err != nil {
+				return err
+			}
+			var err error
+			if query.since, query.until, err = logEventsWindow(since.time, until.time, time.Now()); err != nil {
+				return err
+			}
+			query.groupPrefix = args[0]
+			query.pattern = args[1]
+			query.tags = tagFiltersFromContext(cmd.Context())
+			return finder.SearchPerRegion(
+				cmd.Context(),
+				func(ctx context.Context, conf aws.Config) error {
+					return findLogEvents(ctx, query, cloudwatchlogs.NewFromConfig(conf))
+				})
+		},
+	}
+	cmd.Flags().Var(&since, "since", "Only find events after this time (default 1h)")
+	cmd.Flags().Var(&until, "until", "Only find events before this time")
+	cmd.Flags().IntVar(&query.concurrency, "concurrency", query.concurrency, "Number of log groups to search at once")
+	return cmd
+}
+
+// defaultLogEventsWindow limits how far back events are searched for, as searching is charged by the data scanned.
+const defaultLogEventsWindow = time.Hour
+
+// logEventsWindow defaults since to defaultLogEventsWindow before until, or before now if there's no until.
+func logEventsWindow(since, until, now time.Time) (time.Time, time.Time, error) {
+	if since.IsZero() {
+		end := until
+		if end.IsZero() {
+			end = now
+		}
+		since = end.Add(-defaultLogEventsWindow)
+	}
+	if !until.IsZero() && until.Before(since) {
+		return time.Time{}, time.Time{}, errors.New("until must be after since")
+	}
+	return since, until, nil
+}
+
+type logEventsQuery struct {
+	groupPrefix string
+	pattern     string
+	since       time.Time
+	until       time.Time
+	concurrency int
+	tags        []tagFilter
+}
+
+func findLogEvents(ctx context.Context, query logEventsQuery, client logEventsLister) error {
+	// An empty prefix searches every log group, but DescribeLogGroups rejects it rather than treating it as no prefix
+	var prefix *string
+	if query.groupPrefix != "" {
+		prefix = aws.String(query.groupPrefix)
+	}
+	return forEachLogGroup(
+		ctx, prefix, query.tags, query.concurrency, client,
+		func(ctx context.Context, group string) error {
+			return findEvents(ctx, query, client, group)
+		},
+	)
+}
+
+func findEvents(
+	ctx context.Context, query logEventsQuery, client cloudwatchlogs.FilterLogEventsAPIClient, group string,
+) error {
+	input := &cloudwatchlogs.FilterLogEventsInput{
+		LogGroupName:  aws.String(group),
+		FilterPattern: aws.String(query.pattern),
+		StartTime:     aws.Int64(query.since.UnixMilli()),
+	}
+	if !query.until.IsZero() {
+		input.EndTime = aws.Int64(query.until.UnixMilli())
+	}
+	pages := cloudwatchlogs.NewFilterLogEventsPaginator(client, input)
+
+	for event, err := range paginatorToSeq(ctx, pages, filteredEventsToEvent) {
+		if err != nil {
+			return err
+		}
+
+		log.Logger(ctx).InfoContext(
+			ctx,
+			strings.TrimRight(aws.ToString(event.Message), "\n"),
+			slog.String("group", group),
+			slog.String("stream", aws.ToString(event.LogStreamName)),
+			slog.Time("timestamp", time.UnixMilli(aws.ToInt64(event.Timestamp)).UTC()),
+		)
+	}
+
+	return nil
+}
+
+func filteredEventsToEvent(r *cloudwatchlogs.FilterLogEventsOutput) iter.Seq[types.FilteredLogEvent] {
+	return slices.Values(r.Events)
+}
+
+type logEventsLister interface {
+	logGroupLister
+	cloudwatchlogs.FilterLogEventsAPIClient
+}
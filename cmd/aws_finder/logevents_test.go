@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func TestFindLogEvents(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    logEventsQuery
+		expected string
+	}{
+		{
+			"since",
+			logEventsQuery{groupPrefix: "/app", pattern: "req-1", since: time.UnixMilli(1500), concurrency: 1},
+			"level=INFO msg=\"handled req-1\" group=/app/api stream=web-2 timestamp=1970-01-01T00:00:02.000Z\n",
+		},
+		{
+			"window",
+			logEventsQuery{
+				groupPrefix: "/app",
+				pattern:     "req-1",
+				since:       time.UnixMilli(0),
+				until:       time.UnixMilli(1500),
+				concurrency: 1,
+			},
+			"level=INFO msg=\"received req-1\" group=/app/api stream=web-1 timestamp=1970-01-01T00:00:01.000Z\n",
+		},
+		{
+			"every group",
+			logEventsQuery{pattern: "req-2", since: time.UnixMilli(0), concurrency: 1},
+			"level=INFO msg=\"handled req-2\" group=/app/api stream=web-2 timestamp=1970-01-01T00:00:02.000Z\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			require.NoError(t, findLogEvents(ctx, test.query, &logEvents{
+				groupPrefix: test.query.groupPrefix,
+				events: map[string][]types.FilteredLogEvent{
+					"/app/api": {
+						{
+							LogStreamName: aws.String("web-1"),
+							Timestamp:     aws.Int64(1000),
+							Message:       aws.String("received req-1\n"),
+						},
+						{
+							LogStreamName: aws.String("web-2"),
+							Timestamp:     aws.Int64(2000),
+							Message:       aws.String("handled req-1\n"),
+						},
+						{
+							LogStreamName: aws.String("web-2"),
+							Timestamp:     aws.Int64(2000),
+							Message:       aws.String("handled req-2\n"),
+						},
+					},
+				},
+			}))
+
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+var _ logEventsLister = &logEvents{}
+
+type logEvents struct {
+	groupPrefix string
+	events      map[string][]types.FilteredLogEvent
+}
+
+func (l *logEvents) DescribeLogGroups(
+	ctx context.Context, input *cloudwatchlogs.DescribeLogGroupsInput, _ ...func(*cloudwatchlogs.Options),
+) (*cloudwatchlogs.DescribeLogGroupsOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if input.LogGroupNamePrefix != nil && *input.LogGroupNamePrefix == "" {
+		return nil, errors.New("prefix must be at least 1 character")
+	}
+	if aws.ToString(input.LogGroupNamePrefix) != l.groupPrefix {
+		return nil, errors.New("invalid prefix")
+	}
+
+	var groups []types.LogGroup
+	for name := range l.events {
+		groups = append(groups, types.LogGroup{LogGroupName: aws.String(name)})
+	}
+	return &cloudwatchlogs.DescribeLogGroupsOutput{LogGroups: groups}, nil
+}
+
+func (l *logEvents) ListTagsForResource(
+	_ context.Context, _ *cloudwatchlogs.ListTagsForResourceInput, _ ...func(*cloudwatchlogs.Options),
+) (*cloudwatchlogs.ListTagsForResourceOutput, error) {
+	return nil, errors.New("unexpected call to list tags")
+}
+
+// FilterLogEvents treats the pattern as a plain term to look for in each message.
+func (l *logEvents) FilterLogEvents(
+	ctx context.Context, input *cloudwatchlogs.FilterLogEventsInput, _ ...func(*cloudwatchlogs.Options),
+) (*cloudwatchlogs.FilterLogEventsOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if input.StartTime == nil {
+		return nil, errors.New("missing start time")
+	}
+
+	var events []types.FilteredLogEvent
+	for _, event := range l.events[aws.ToString(input.LogGroupName)] {
+		timestamp := aws.ToInt64(event.Timestamp)
+		if timestamp < *input.StartTime || (input.EndTime != nil && timestamp > *input.EndTime) {
+			continue
+		}
+		if strings.Contains(aws.ToString(event.Message), aws.ToString(input.FilterPattern)) {
+			events = append(events, event)
+		}
+	}
+	return &cloudwatchlogs.FilterLogEventsOutput{Events: events}, nil
+}
+
+func TestLogEventsWindow(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		name          string
+		since         time.Time
+		until         time.Time
+		expectedSince time.Time
+		expectedUntil time.Time
+	}{
+		{
+			"defaults to the last hour",
+			time.Time{},
+			time.Time{},
+			time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC),
+			time.Time{},
+		},
+		{
+			"defaults to the hour before until",
+			time.Time{},
+			time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			"keeps since",
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			since, until, err := logEventsWindow(test.since, test.until, now)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSince, since)
+			assert.Equal(t, test.expectedUntil, until)
+		})
+	}
+}
+
+func TestLogEventsWindow_UntilBeforeSince(t *testing.T) {
+	_, _, err := logEventsWindow(
+		time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Now(),
+	)
+	require.EqualError(t, err, "until must be after since")
+}
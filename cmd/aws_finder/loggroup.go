@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"iter"
+	"log/slog"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -15,28 +19,146 @@ import (
 )
 
 func logGroupCmd() *cobra.Command {
-	return &cobra.Command{
+	var query logGroupQuery
+	var maxStoredBytes int64
+	cmd := &cobra.Command{
 		Use:   "log_group [needle]",
 		Short: "Find a CloudWatch log group by name",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := query.validate(); err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("max-stored-bytes") {
+				query.maxStoredBytes = &maxStoredBytes
+			}
+			if len(args) == 1 {
+				query.needle = args[0]
+			}
+			query.tags = tagFiltersFromContext(cmd.Context())
 			return finder.SearchPerRegion(
 				cmd.Context(),
 				func(ctx context.Context, conf aws.Config) error {
-					return findLogGroup(ctx, args[0], cloudwatchlogs.NewFromConfig(conf))
+					return findLogGroup(ctx, query, cloudwatchlogs.NewFromConfig(conf))
 				})
 		},
 	}
+	cmd.Flags().StringVar(
+		&query.retention,
+		"retention",
+		"",
+		fmt.Sprintf("Only find log groups keeping events for this many days, or %q", neverExpire),
+	)
+	cmd.Flags().StringVar(&query.kmsKey, "kms-key", "", "Only find log groups encrypted with this KMS key")
+	cmd.Flags().BoolVar(&query.unencrypted, "unencrypted", false, "Only find log groups not encrypted with a KMS key")
+	cmd.Flags().StringVar(
+		&query.class,
+		"class",
+		"",
+		fmt.Sprintf(
+			"Only find log groups of this class (%s)",
+			strings.Join(enumStrings(types.LogGroupClass("").Values()), ", "),
+		),
+	)
+	cmd.Flags().StringVar(
+		&query.dataProtection,
+		"data-protection",
+		"",
+		fmt.Sprintf(
+			"Only find log groups with this data protection status (%s)",
+			strings.Join(enumStrings(types.DataProtectionStatus("").Values()), ", "),
+		),
+	)
+	cmd.Flags().Int64Var(
+		&query.minStoredBytes, "min-stored-bytes", 0, "Only find log groups storing at least this many bytes",
+	)
+	cmd.Flags().Int64Var(
+		&maxStoredBytes, "max-stored-bytes", 0, "Only find log groups storing at most this many bytes",
+	)
+	cmd.Flags().StringVar(
+		&query.destinations.subscriptionDestination,
+		"subscription-destination",
+		"",
+		"Only find log groups with a subscription filter sending events to this Kinesis, Firehose or Lambda ARN",
+	)
+	cmd.Flags().StringVar(
+		&query.destinations.metricNamespace,
+		"metric-namespace",
+		"",
+		"Only find log groups with a metric filter publishing to this metric namespace",
+	)
+	return cmd
 }
 
-func findLogGroup(
-	ctx context.Context, needle string, client cloudwatchlogs.DescribeLogGroupsAPIClient,
-) error {
-	pages := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, nil)
+const neverExpire = "never"
+
+type logGroupQuery struct {
+	needle         string
+	retention      string
+	kmsKey         string
+	unencrypted    bool
+	class          string
+	dataProtection string
+	minStoredBytes int64
+	maxStoredBytes *int64
+	destinations   logGroupDestinations
+	tags           []tagFilter
+}
+
+func (q logGroupQuery) validate() error {
+	if q.retention != "" && q.retention != neverExpire {
+		if _, err := strconv.Atoi(q.retention); err != nil {
+			return fmt.Errorf("retention must be a number of days or %q, not %q", neverExpire, q.retention)
+		}
+	}
+	if q.kmsKey != "" && q.unencrypted {
+		return errors.New("cannot find log groups both encrypted with a KMS key and unencrypted")
+	}
+	if q.class != "" && !slices.Contains(enumStrings(types.LogGroupClass("").Values()), q.class) {
+		return fmt.Errorf("unknown log group class %q", q.class)
+	}
+	dataProtectionStates := enumStrings(types.DataProtectionStatus("").Values())
+	if q.dataProtection != "" && !slices.Contains(dataProtectionStates, q.dataProtection) {
+		return fmt.Errorf("unknown data protection status %q", q.dataProtection)
+	}
+	return nil
+}
+
+func (q logGroupQuery) matches(group types.LogGroup) bool {
+	if !strings.Contains(aws.ToString(group.LogGroupName), q.needle) {
+		return false
+	}
+	if q.retention != "" && logGroupRetention(group) != q.retention {
+		return false
+	}
+	if q.kmsKey != "" && !kmsKeyMatches(aws.ToString(group.KmsKeyId), q.kmsKey) {
+		return false
+	}
+	if q.unencrypted && group.KmsKeyId != nil {
+		return false
+	}
+	if q.dataProtection != "" && logGroupDataProtection(group) != q.dataProtection {
+		return false
+	}
+	storedBytes := aws.ToInt64(group.StoredBytes)
+	if storedBytes < q.minStoredBytes {
+		return false
+	}
+	return q.maxStoredBytes == nil || storedBytes <= *q.maxStoredBytes
+}
+
+func findLogGroup(ctx context.Context, query logGroupQuery, client logGroupFinder) error {
+	if err := query.destinations.load(ctx, client); err != nil {
+		return err
+	}
+
+	pages := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, &cloudwatchlogs.DescribeLogGroupsInput{
+		LogGroupClass: types.LogGroupClass(query.class),
+	})
 
 	seq := paginatorToSeq(ctx, pages, logGroupListToItems)
 	seq = filter2(func(g types.LogGroup, err error) bool {
-		return err != nil || strings.Contains(aws.ToString(g.LogGroupName), needle)
+		return err != nil || query.matches(g)
 	}, seq)
 
 	for g, err := range seq {
@@ -44,12 +166,95 @@ func findLogGroup(
 			return err
 		}
 
-		log.Logger(ctx).InfoContext(ctx, aws.ToString(g.LogGroupName))
+		matched, destinationAttrs, err := query.destinations.matches(ctx, client, g)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		matched, err = logGroupMatchesTags(ctx, query.tags, client, g)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		log.Logger(ctx).InfoContext(ctx, aws.ToString(g.LogGroupName), append(logGroupAttrs(g), destinationAttrs...)...)
 	}
 
 	return nil
 }
 
+func logGroupAttrs(group types.LogGroup) []any {
+	attrs := []any{slog.String("retention", logGroupRetention(group))}
+	if group.KmsKeyId != nil {
+		attrs = append(attrs, slog.String("kms-key", aws.ToString(group.KmsKeyId)))
+	}
+	if group.LogGroupClass != "" {
+		attrs = append(attrs, slog.String("class", string(group.LogGroupClass)))
+	}
+	return append(
+		attrs,
+		slog.String("data-protection", logGroupDataProtection(group)),
+		slog.Int64("stored-bytes", aws.ToInt64(group.StoredBytes)),
+	)
+}
+
+// logGroupRetention returns the number of days events are kept for, where log groups without a retention never
+// expire their events.
+func logGroupRetention(group types.LogGroup) string {
+	if group.RetentionInDays == nil {
+		return neverExpire
+	}
+	return strconv.Itoa(int(*group.RetentionInDays))
+}
+
+// logGroupDataProtection returns the data protection status, which is blank for log groups that never had a policy.
+func logGroupDataProtection(group types.LogGroup) string {
+	if group.DataProtectionStatus == "" {
+		return string(types.DataProtectionStatusDisabled)
+	}
+	return string(group.DataProtectionStatus)
+}
+
 func logGroupListToItems(r *cloudwatchlogs.DescribeLogGroupsOutput) iter.Seq[types.LogGroup] {
 	return slices.Values(r.LogGroups)
 }
+
+// logGroupMatchesTags checks the tags of the log group, which aren't returned by DescribeLogGroups.
+func logGroupMatchesTags(
+	ctx context.Context, tags []tagFilter, client logGroupTagLister, group types.LogGroup,
+) (bool, error) {
+	if len(tags) == 0 {
+		return true, nil
+	}
+
+	output, err := client.ListTagsForResource(ctx, &cloudwatchlogs.ListTagsForResourceInput{
+		ResourceArn: group.LogGroupArn,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list tags for log group %q: %w", aws.ToString(group.LogGroupName), err)
+	}
+
+	return matchesTagFilters(tags, output.Tags), nil
+}
+
+type logGroupTagLister interface {
+	ListTagsForResource(
+		ctx context.Context, params *cloudwatchlogs.ListTagsForResourceInput, optFns ...func(*cloudwatchlogs.Options),
+	) (*cloudwatchlogs.ListTagsForResourceOutput, error)
+}
+
+type logGroupLister interface {
+	cloudwatchlogs.DescribeLogGroupsAPIClient
+	logGroupTagLister
+}
+
+type logGroupFinder interface {
+	logGroupLister
+	cloudwatchlogs.DescribeMetricFiltersAPIClient
+	cloudwatchlogs.DescribeSubscriptionFiltersAPIClient
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// logGroupDestinations finds log groups by where their subscription and metric filters send events.
+type logGroupDestinations struct {
+	subscriptionDestination string
+	metricNamespace         string
+	// metricFilters are the names of the metric filters publishing to the namespace, keyed by log group, which can be
+	// found for every log group at once. DescribeMetricFilters can only filter by namespace along with a metric name,
+	// so every metric filter is listed and matched here.
+	metricFilters map[string][]string
+}
+
+func (d *logGroupDestinations) load(ctx context.Context, client cloudwatchlogs.DescribeMetricFiltersAPIClient) error {
+	if d.metricNamespace == "" {
+		return nil
+	}
+
+	pages := cloudwatchlogs.NewDescribeMetricFiltersPaginator(client, &cloudwatchlogs.DescribeMetricFiltersInput{})
+
+	d.metricFilters = map[string][]string{}
+	for filter, err := range paginatorToSeq(ctx, pages, metricFiltersToFilter) {
+		if err != nil {
+			return fmt.Errorf("failed to find metric filters: %w", err)
+		}
+		if !slices.ContainsFunc(filter.MetricTransformations, func(metric types.MetricTransformation) bool {
+			return aws.ToString(metric.MetricNamespace) == d.metricNamespace
+		}) {
+			continue
+		}
+		group := aws.ToString(filter.LogGroupName)
+		d.metricFilters[group] = append(d.metricFilters[group], aws.ToString(filter.FilterName))
+	}
+	return nil
+}
+
+// matches returns whether the log group has the wanted filters, along with their names so they can be logged.
+func (d *logGroupDestinations) matches(
+	ctx context.Context, client cloudwatchlogs.DescribeSubscriptionFiltersAPIClient, group types.LogGroup,
+) (bool, []any, error) {
+	var attrs []any
+	if d.metricNamespace != "" {
+		names, ok := d.metricFilters[aws.ToString(group.LogGroupName)]
+		if !ok {
+			return false, nil, nil
+		}
+		attrs = append(attrs, slog.String("metric-filters", strings.Join(names, ",")))
+	}
+
+	if d.subscriptionDestination != "" {
+		names, err := d.subscriptionFilters(ctx, client, group)
+		if err != nil {
+			return false, nil, err
+		}
+		if len(names) == 0 {
+			return false, nil, nil
+		}
+		attrs = append(attrs, slog.String("subscription-filters", strings.Join(names, ",")))
+	}
+
+	return true, attrs, nil
+}
+
+func (d *logGroupDestinations) subscriptionFilters(
+	ctx context.Context, client cloudwatchlogs.DescribeSubscriptionFiltersAPIClient, group types.LogGroup,
+) ([]string, error) {
+	pages := cloudwatchlogs.NewDescribeSubscriptionFiltersPaginator(
+		client,
+		&cloudwatchlogs.DescribeSubscriptionFiltersInput{LogGroupName: group.LogGroupName},
+	)
+
+	var names []string
+	for filter, err := range paginatorToSeq(ctx, pages, subscriptionFiltersToFilter) {
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to find subscription filters for log group %q: %w", aws.ToString(group.LogGroupName), err,
+			)
+		}
+		if destinationMatches(aws.ToString(filter.DestinationArn), d.subscriptionDestination) {
+			names = append(names, aws.ToString(filter.FilterName))
+		}
+	}
+	return names, nil
+}
+
+// destinationMatches compares ARNs, where a Lambda function also matches its versions and aliases.
+func destinationMatches(destination, wanted string) bool {
+	return destination == wanted || strings.HasPrefix(destination, wanted+":")
+}
+
+func metricFiltersToFilter(r *cloudwatchlogs.DescribeMetricFiltersOutput) iter.Seq[types.MetricFilter] {
+	return slices.Values(r.MetricFilters)
+}
+
+func subscriptionFiltersToFilter(
+	r *cloudwatchlogs.DescribeSubscriptionFiltersOutput,
+) iter.Seq[types.SubscriptionFilter] {
+	return slices.Values(r.SubscriptionFilters)
+}
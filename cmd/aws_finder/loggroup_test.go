@@ -27,7 +27,7 @@ func TestFindLogGroup(t *testing.T) {
 		}),
 	}))
 
-	require.NoError(t, findLogGroup(ctx, "find", &logGroups{
+	require.NoError(t, findLogGroup(ctx, logGroupQuery{needle: "find"}, &logGroups{
 		data: [][]types.LogGroup{
 			{
 				{
@@ -48,14 +48,259 @@ func TestFindLogGroup(t *testing.T) {
 		},
 	}))
 
-	assert.Equal(t, `level=INFO msg="one to find"
+	assert.Equal(t, `level=INFO msg="one to find" retention=never data-protection=DISABLED stored-bytes=0
 `, buf.String())
 }
 
-var _ cloudwatchlogs.DescribeLogGroupsAPIClient = &logGroups{}
+func TestFindLogGroup_Tags(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	team, err := parseTagFilter("team=pay*")
+	require.NoError(t, err)
+
+	require.NoError(t, findLogGroup(ctx, logGroupQuery{needle: "find", tags: []tagFilter{team}}, &logGroups{
+		data: [][]types.LogGroup{
+			{
+				{
+					LogGroupName: aws.String("untagged to find"),
+					LogGroupArn:  aws.String("arn:untagged"),
+				},
+				{
+					LogGroupName: aws.String("wrong team to find"),
+					LogGroupArn:  aws.String("arn:wrong"),
+				},
+				{
+					LogGroupName: aws.String("one to find"),
+					LogGroupArn:  aws.String("arn:right"),
+				},
+			},
+		},
+		tags: map[string]map[string]string{
+			"arn:wrong": {"team": "platform"},
+			"arn:right": {"team": "payments"},
+		},
+	}))
+
+	assert.Equal(t, `level=INFO msg="one to find" retention=never data-protection=DISABLED stored-bytes=0
+`, buf.String())
+}
+
+func TestFindLogGroup_Filters(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    logGroupQuery
+		expected string
+	}{
+		{
+			"never-expire",
+			logGroupQuery{retention: "never"},
+			"level=INFO msg=/app/forever retention=never class=STANDARD data-protection=DISABLED stored-bytes=2048\n",
+		},
+		{
+			"retention-days",
+			logGroupQuery{retention: "30"},
+			"level=INFO msg=/app/encrypted retention=30 kms-key=arn:aws:kms:eu-west-1:123456789012:key/1234abcd " +
+				"class=STANDARD data-protection=ACTIVATED stored-bytes=100\n",
+		},
+		{
+			"kms-key",
+			logGroupQuery{kmsKey: "1234abcd"},
+			"level=INFO msg=/app/encrypted retention=30 kms-key=arn:aws:kms:eu-west-1:123456789012:key/1234abcd " +
+				"class=STANDARD data-protection=ACTIVATED stored-bytes=100\n",
+		},
+		{
+			"unencrypted",
+			logGroupQuery{unencrypted: true, needle: "/app"},
+			"level=INFO msg=/app/forever retention=never class=STANDARD data-protection=DISABLED stored-bytes=2048\n",
+		},
+		{
+			"data-protection",
+			logGroupQuery{dataProtection: "DISABLED", needle: "/app"},
+			"level=INFO msg=/app/forever retention=never class=STANDARD data-protection=DISABLED stored-bytes=2048\n",
+		},
+		{
+			"class",
+			logGroupQuery{class: "INFREQUENT_ACCESS", needle: "/other"},
+			"level=INFO msg=/other/empty retention=1 class=INFREQUENT_ACCESS data-protection=DISABLED stored-bytes=0\n",
+		},
+		{
+			"stored-bytes",
+			logGroupQuery{minStoredBytes: 50, maxStoredBytes: aws.Int64(1024)},
+			"level=INFO msg=/app/encrypted retention=30 kms-key=arn:aws:kms:eu-west-1:123456789012:key/1234abcd " +
+				"class=STANDARD data-protection=ACTIVATED stored-bytes=100\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			require.NoError(t, test.query.validate())
+			require.NoError(t, findLogGroup(ctx, test.query, &logGroups{
+				data: [][]types.LogGroup{
+					{
+						{
+							LogGroupName:  aws.String("/app/forever"),
+							LogGroupClass: types.LogGroupClassStandard,
+							StoredBytes:   aws.Int64(2048),
+						},
+						{
+							LogGroupName:         aws.String("/app/encrypted"),
+							LogGroupClass:        types.LogGroupClassStandard,
+							RetentionInDays:      aws.Int32(30),
+							KmsKeyId:             aws.String("arn:aws:kms:eu-west-1:123456789012:key/1234abcd"),
+							DataProtectionStatus: types.DataProtectionStatusActivated,
+							StoredBytes:          aws.Int64(100),
+						},
+						{
+							LogGroupName:    aws.String("/other/empty"),
+							LogGroupClass:   types.LogGroupClassInfrequentAccess,
+							RetentionInDays: aws.Int32(1),
+							StoredBytes:     aws.Int64(0),
+						},
+					},
+				},
+				class: types.LogGroupClass(test.query.class),
+			}))
+
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestFindLogGroup_Destinations(t *testing.T) {
+	var tests = []struct {
+		name         string
+		destinations logGroupDestinations
+		expected     string
+	}{
+		{
+			"lambda",
+			logGroupDestinations{subscriptionDestination: "arn:aws:lambda:eu-west-1:123456789012:function:shipper"},
+			"level=INFO msg=/app/lambda retention=never data-protection=DISABLED stored-bytes=0 " +
+				"subscription-filters=ship\n",
+		},
+		{
+			"firehose",
+			logGroupDestinations{
+				subscriptionDestination: "arn:aws:firehose:eu-west-1:123456789012:deliverystream/logs",
+			},
+			"level=INFO msg=/app/firehose retention=never data-protection=DISABLED stored-bytes=0 " +
+				"subscription-filters=to-firehose\n",
+		},
+		{
+			"metric-namespace",
+			logGroupDestinations{metricNamespace: "App/Errors"},
+			"level=INFO msg=/app/firehose retention=never data-protection=DISABLED stored-bytes=0 " +
+				"metric-filters=errors,fatals\n",
+		},
+		{
+			"both",
+			logGroupDestinations{
+				metricNamespace:         "App/Errors",
+				subscriptionDestination: "arn:aws:lambda:eu-west-1:123456789012:function:shipper",
+			},
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			errorsMetric := []types.MetricTransformation{{MetricNamespace: aws.String("App/Errors")}}
+			require.NoError(t, findLogGroup(ctx, logGroupQuery{destinations: test.destinations}, &logGroups{
+				data: [][]types.LogGroup{
+					{
+						{LogGroupName: aws.String("/app/lambda")},
+						{LogGroupName: aws.String("/app/firehose")},
+						{LogGroupName: aws.String("/app/none")},
+					},
+				},
+				metricFilters: []types.MetricFilter{
+					{
+						FilterName:            aws.String("errors"),
+						LogGroupName:          aws.String("/app/firehose"),
+						MetricTransformations: errorsMetric,
+					},
+					{
+						FilterName:            aws.String("fatals"),
+						LogGroupName:          aws.String("/app/firehose"),
+						MetricTransformations: errorsMetric,
+					},
+					{
+						FilterName:   aws.String("latency"),
+						LogGroupName: aws.String("/app/lambda"),
+						MetricTransformations: []types.MetricTransformation{
+							{MetricNamespace: aws.String("App/Latency")},
+						},
+					},
+				},
+				subscriptionFilters: map[string][]types.SubscriptionFilter{
+					"/app/lambda": {
+						{
+							FilterName:     aws.String("ship"),
+							DestinationArn: aws.String("arn:aws:lambda:eu-west-1:123456789012:function:shipper:live"),
+						},
+					},
+					"/app/firehose": {
+						{
+							FilterName:     aws.String("to-firehose"),
+							DestinationArn: aws.String("arn:aws:firehose:eu-west-1:123456789012:deliverystream/logs"),
+						},
+						{
+							FilterName:     aws.String("to-shipper-v2"),
+							DestinationArn: aws.String("arn:aws:lambda:eu-west-1:123456789012:function:shipper-v2"),
+						},
+					},
+				},
+			}))
+
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestLogGroupQuery_Validate(t *testing.T) {
+	require.NoError(t, logGroupQuery{retention: "never", class: "STANDARD"}.validate())
+	require.EqualError(
+		t,
+		logGroupQuery{retention: "forever"}.validate(),
+		`retention must be a number of days or "never", not "forever"`,
+	)
+	require.EqualError(t, logGroupQuery{class: "COLD"}.validate(), `unknown log group class "COLD"`)
+	require.Error(t, logGroupQuery{kmsKey: "key", unencrypted: true}.validate())
+}
+
+var _ logGroupFinder = &logGroups{}
 
 type logGroups struct {
-	data [][]types.LogGroup
+	data                [][]types.LogGroup
+	tags                map[string]map[string]string
+	class               types.LogGroupClass
+	metricFilters       []types.MetricFilter
+	subscriptionFilters map[string][]types.SubscriptionFilter
 }
 
 func (l *logGroups) DescribeLogGroups(
@@ -67,6 +312,9 @@ func (l *logGroups) DescribeLogGroups(
 	if aws.ToString(input.LogGroupNamePrefix) != "" {
 		return nil, errors.New("invalid prefix")
 	}
+	if input.LogGroupClass != l.class {
+		return nil, errors.New("invalid class")
+	}
 
 	var value []types.LogGroup
 	value, l.data = l.data[0], l.data[1:]
@@ -81,3 +329,44 @@ func (l *logGroups) DescribeLogGroups(
 		NextToken: token,
 	}, nil
 }
+
+func (l *logGroups) ListTagsForResource(
+	ctx context.Context, input *cloudwatchlogs.ListTagsForResourceInput, _ ...func(*cloudwatchlogs.Options),
+) (*cloudwatchlogs.ListTagsForResourceOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+
+	return &cloudwatchlogs.ListTagsForResourceOutput{
+		Tags: l.tags[aws.ToString(input.ResourceArn)],
+	}, nil
+}
+
+func (l *logGroups) DescribeMetricFilters(
+	ctx context.Context, input *cloudwatchlogs.DescribeMetricFiltersInput, _ ...func(*cloudwatchlogs.Options),
+) (*cloudwatchlogs.DescribeMetricFiltersOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if input.LogGroupName != nil {
+		return nil, errors.New("invalid input")
+	}
+	// The namespace and metric name can only be used together
+	if (input.MetricNamespace == nil) != (input.MetricName == nil) {
+		return nil, errors.New("metric namespace and name must be given together")
+	}
+
+	return &cloudwatchlogs.DescribeMetricFiltersOutput{MetricFilters: l.metricFilters}, nil
+}
+
+func (l *logGroups) DescribeSubscriptionFilters(
+	ctx context.Context, input *cloudwatchlogs.DescribeSubscriptionFiltersInput, _ ...func(*cloudwatchlogs.Options),
+) (*cloudwatchlogs.DescribeSubscriptionFiltersOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+
+	return &cloudwatchlogs.DescribeSubscriptionFiltersOutput{
+		SubscriptionFilters: l.subscriptionFilters[aws.ToString(input.LogGroupName)],
+	}, nil
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"iter"
+	"log/slog"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
@@ -13,48 +16,156 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/wjam/aws_finder/internal/finder"
 	"github.com/wjam/aws_finder/internal/log"
+	"golang.org/x/sync/errgroup"
 )
 
 func logStreamCmd() *cobra.Command {
-	return &cobra.Command{
+	query := logStreamQuery{concurrency: defaultLogGroupConcurrency}
+	var since, until timeFlag
+	cmd := &cobra.Command{
 		Use:   "log_stream <logGroupPrefix> [needle]",
 		Short: "Find a CloudWatch log stream by name",
 		Args:  cobra.RangeArgs(1, 2), //nolint:mnd // up to 2 arguments
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateConcurrency(query.concurrency); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				query.needle = args[0]
+			} else {
+				query.groupPrefix = aws.String(args[0])
+				query.needle = args[1]
+			}
+			if !since.time.IsZero() && !until.time.IsZero() && until.time.Before(since.time) {
+				return errors.New("until must be after since")
+			}
+			query.since = since.time
+			query.until = until.time
+			query.tags = tagFiltersFromContext(cmd.Context())
 			return finder.SearchPerRegion(
 				cmd.Context(),
 				func(ctx context.Context, conf aws.Config) error {
-					var group *string
-					var needle string
-					if len(args) == 1 {
-						needle = args[0]
-					} else {
-						group = aws.String(args[0])
-						needle = args[1]
-					}
-					return findLogStream(ctx, group, needle, cloudwatchlogs.NewFromConfig(conf))
+					return findLogStream(ctx, query, cloudwatchlogs.NewFromConfig(conf))
 				})
 		},
 	}
+	cmd.Flags().BoolVar(
+		&query.prefix, "prefix", false, "Only find streams starting with the needle, which is quicker to search for",
+	)
+	cmd.Flags().Int32Var(
+		&query.latest, "latest", 0, "Only find this many of the most recently written to matching streams per group",
+	)
+	cmd.Flags().BoolVar(
+		&query.byLastEvent, "by-last-event", false, "Find the most recently written to streams in each group first",
+	)
+	cmd.MarkFlagsMutuallyExclusive("prefix", "latest")
+	cmd.MarkFlagsMutuallyExclusive("prefix", "by-last-event")
+	cmd.Flags().Var(&since, "since", "Only find streams with events after this time")
+	cmd.Flags().Var(&until, "until", "Only find streams with events before this time")
+	cmd.Flags().IntVar(&query.concurrency, "concurrency", query.concurrency, "Number of log groups to search at once")
+	return cmd
 }
 
-func findLogStream(
-	ctx context.Context, groupPrefix *string, needle string, client logStreamLister,
+type logStreamQuery struct {
+	groupPrefix *string
+	needle      string
+	// prefix pushes the needle down to DescribeLogStreams, which can't be combined with ordering by last event.
+	prefix      bool
+	latest      int32
+	byLastEvent bool
+	since       time.Time
+	until       time.Time
+	concurrency int
+	tags        []tagFilter
+}
+
+func (q logStreamQuery) orderByLastEvent() bool {
+	return q.byLastEvent || q.latest > 0
+}
+
+func (q logStreamQuery) hasTimeWindow() bool {
+	return !q.since.IsZero() || !q.until.IsZero()
+}
+
+// logStreamLastEventLag is how far behind the last event time of a stream can be, as it isn't updated on every event.
+const logStreamLastEventLag = time.Hour
+
+// activeDuringWindow checks the stream had events between since and until.
+func (q logStreamQuery) activeDuringWindow(stream types.LogStream) bool {
+	if !q.hasTimeWindow() {
+		return true
+	}
+	if stream.FirstEventTimestamp == nil || stream.LastEventTimestamp == nil {
+		return false
+	}
+	if q.endedBeforeSince(stream) {
+		return false
+	}
+	return q.until.IsZero() || !time.UnixMilli(*stream.FirstEventTimestamp).After(q.until)
+}
+
+// endedBeforeSince checks the last event of the stream was before since, allowing for its last event time being behind.
+func (q logStreamQuery) endedBeforeSince(stream types.LogStream) bool {
+	return !q.since.IsZero() &&
+		time.UnixMilli(aws.ToInt64(stream.LastEventTimestamp)).Add(logStreamLastEventLag).Before(q.since)
+}
+
+// findLogStream searches the streams of several log groups at once, as each group needs its own calls.
+func findLogStream(ctx context.Context, query logStreamQuery, client logStreamLister) error {
+	return forEachLogGroup(
+		ctx, query.groupPrefix, query.tags, query.concurrency, client,
+		func(ctx context.Context, group string) error {
+			return findStream(ctx, query, client, group)
+		},
+	)
+}
+
+// defaultLogGroupConcurrency is enough log groups to search at once to be quicker without being throttled.
+const defaultLogGroupConcurrency = 8
+
+// forEachLogGroup calls f for every log group with the prefix and tags, calling it for up to concurrency groups at
+// once.
+func forEachLogGroup(
+	ctx context.Context,
+	groupPrefix *string,
+	tags []tagFilter,
+	concurrency int,
+	client logGroupLister,
+	f func(ctx context.Context, group string) error,
 ) error {
 	pages := cloudwatchlogs.NewDescribeLogGroupsPaginator(client, &cloudwatchlogs.DescribeLogGroupsInput{
 		LogGroupNamePrefix: groupPrefix,
 	})
 
+	wg, ctx := errgroup.WithContext(ctx)
+	wg.SetLimit(concurrency)
+
+	var groupsErr error
 	for g, err := range paginatorToSeq(ctx, pages, logGroupsToLogGroup) {
 		if err != nil {
-			return err
+			groupsErr = err
+			break
 		}
 
-		if err := findStream(ctx, needle, client, aws.ToString(g.LogGroupName)); err != nil {
-			return err
-		}
+		wg.Go(func() error {
+			matched, err := logGroupMatchesTags(ctx, tags, client, g)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				return nil
+			}
+			return f(ctx, aws.ToString(g.LogGroupName))
+		})
 	}
 
+	return errors.Join(groupsErr, wg.Wait())
+}
+
+func validateConcurrency(concurrency int) error {
+	if concurrency < 1 {
+		return errors.New("concurrency must be at least 1")
+	}
 	return nil
 }
 
@@ -63,33 +174,65 @@ func logGroupsToLogGroup(r *cloudwatchlogs.DescribeLogGroupsOutput) iter.Seq[typ
 }
 
 func findStream(
-	ctx context.Context, needle string, client cloudwatchlogs.DescribeLogStreamsAPIClient, group string,
+	ctx context.Context, query logStreamQuery, client cloudwatchlogs.DescribeLogStreamsAPIClient, group string,
 ) error {
-	pages := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, &cloudwatchlogs.DescribeLogStreamsInput{
+	input := &cloudwatchlogs.DescribeLogStreamsInput{
 		LogGroupName: aws.String(group),
-	})
+	}
+	if query.prefix {
+		input.LogStreamNamePrefix = aws.String(query.needle)
+	}
+	if query.orderByLastEvent() {
+		input.OrderBy = types.OrderByLastEventTime
+		input.Descending = aws.Bool(true)
+	}
+	pages := cloudwatchlogs.NewDescribeLogStreamsPaginator(client, input)
 
 	seq := paginatorToSeq(ctx, pages, logStreamToLogStream)
-	seq = filter2(func(s types.LogStream, err error) bool {
-		return err != nil || strings.Contains(aws.ToString(s.LogStreamName), needle)
-	}, seq)
 
+	var found int32
 	for s, err := range seq {
 		if err != nil {
 			return err
 		}
 
-		log.Logger(ctx).InfoContext(ctx, fmt.Sprintf("%s/%s", group, aws.ToString(s.LogStreamName)))
+		// Streams are ordered by their last event, so no later stream can be in the window
+		if query.orderByLastEvent() && query.endedBeforeSince(s) {
+			return nil
+		}
+		if !strings.Contains(aws.ToString(s.LogStreamName), query.needle) || !query.activeDuringWindow(s) {
+			continue
+		}
+
+		log.Logger(ctx).InfoContext(
+			ctx, fmt.Sprintf("%s/%s", group, aws.ToString(s.LogStreamName)), logStreamAttrs(query, s)...,
+		)
+
+		// Streams are ordered by their last event, so no later stream is more recent
+		found++
+		if found == query.latest {
+			return nil
+		}
 	}
 
 	return nil
 }
 
+func logStreamAttrs(query logStreamQuery, stream types.LogStream) []any {
+	if !query.hasTimeWindow() {
+		return nil
+	}
+	return []any{
+		slog.Time("first-event", time.UnixMilli(aws.ToInt64(stream.FirstEventTimestamp)).UTC()),
+		slog.Time("last-event", time.UnixMilli(aws.ToInt64(stream.LastEventTimestamp)).UTC()),
+	}
+}
+
 func logStreamToLogStream(r *cloudwatchlogs.DescribeLogStreamsOutput) iter.Seq[types.LogStream] {
 	return slices.Values(r.LogStreams)
 }
 
 type logStreamLister interface {
-	cloudwatchlogs.DescribeLogGroupsAPIClient
+	logGroupLister
 	cloudwatchlogs.DescribeLogStreamsAPIClient
 }
@@ -2,12 +2,17 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	cloudwatchlogs2 "github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
@@ -27,7 +32,7 @@ func TestFindLogStream_AllLogGroups(t *testing.T) {
 		}),
 	}))
 
-	require.NoError(t, findLogStream(ctx, nil, "find", &logStreams{
+	require.NoError(t, findLogStream(ctx, logStreamQuery{needle: "find", concurrency: 2}, &logStreams{
 		logs: map[string][]types.LogStream{
 			"first": {
 				{
@@ -69,7 +74,11 @@ func TestFindLogStream_SpecificLogGroups(t *testing.T) {
 		}),
 	}))
 
-	require.NoError(t, findLogStream(ctx, aws.String("expected-prefix"), "find", &logStreams{
+	require.NoError(t, findLogStream(ctx, logStreamQuery{
+		groupPrefix: aws.String("expected-prefix"),
+		needle:      "find",
+		concurrency: 2,
+	}, &logStreams{
 		logStreamPrefix: "expected-prefix",
 		logs: map[string][]types.LogStream{
 			"expected-prefix": {
@@ -86,6 +95,174 @@ func TestFindLogStream_SpecificLogGroups(t *testing.T) {
 	assert.Equal(t, "level=INFO msg=\"expected-prefix/one to find\"\n", buf.String())
 }
 
+func TestFindLogStream_Ordering(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    logStreamQuery
+		expected string
+	}{
+		{
+			"prefix",
+			logStreamQuery{needle: "web", prefix: true, concurrency: 1},
+			"level=INFO msg=first/web-1\nlevel=INFO msg=first/web-2\nlevel=INFO msg=second/web-3\n",
+		},
+		{
+			"latest",
+			logStreamQuery{needle: "web", latest: 1, concurrency: 1},
+			"level=INFO msg=first/web-2\nlevel=INFO msg=second/web-3\n",
+		},
+		{
+			"all",
+			logStreamQuery{needle: "web", concurrency: 1},
+			"level=INFO msg=first/web-1\nlevel=INFO msg=first/web-2\nlevel=INFO msg=first/old-web\n" +
+				"level=INFO msg=second/web-3\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			require.NoError(t, findLogStream(ctx, test.query, &logStreams{
+				logs: map[string][]types.LogStream{
+					"first": {
+						{LogStreamName: aws.String("web-1"), LastEventTimestamp: aws.Int64(200)},
+						{LogStreamName: aws.String("web-2"), LastEventTimestamp: aws.Int64(300)},
+						{LogStreamName: aws.String("old-web"), LastEventTimestamp: aws.Int64(100)},
+						{LogStreamName: aws.String("api-1"), LastEventTimestamp: aws.Int64(400)},
+					},
+					"second": {
+						{LogStreamName: aws.String("web-3"), LastEventTimestamp: aws.Int64(100)},
+					},
+				},
+			}))
+
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestFindLogStream_Concurrent(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	logs := map[string][]types.LogStream{}
+	var expected []string
+	for i := range 20 {
+		group := fmt.Sprintf("group-%d", i)
+		logs[group] = []types.LogStream{{LogStreamName: aws.String("find")}, {LogStreamName: aws.String("miss")}}
+		expected = append(expected, fmt.Sprintf("level=INFO msg=%s/find", group))
+	}
+
+	require.NoError(t, findLogStream(ctx, logStreamQuery{needle: "find", concurrency: 4}, &logStreams{logs: logs}))
+
+	assert.ElementsMatch(t, expected, strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"))
+}
+
+func TestFindLogStream_TimeWindow(t *testing.T) {
+	at := func(d time.Duration) time.Time {
+		return time.UnixMilli(d.Milliseconds())
+	}
+	timestamp := func(d time.Duration) *int64 {
+		return aws.Int64(d.Milliseconds())
+	}
+
+	var tests = []struct {
+		name     string
+		query    logStreamQuery
+		expected string
+	}{
+		{
+			"since",
+			logStreamQuery{needle: "web", since: at(5 * time.Hour), concurrency: 1},
+			"level=INFO msg=first/web-2 first-event=1970-01-01T05:00:00.000Z last-event=1970-01-01T06:00:00.000Z\n" +
+				"level=INFO msg=first/web-3 first-event=1970-01-01T03:00:00.000Z last-event=1970-01-01T04:30:00.000Z\n",
+		},
+		{
+			"last event lagging",
+			logStreamQuery{needle: "web-3", since: at(5*time.Hour + 15*time.Minute), concurrency: 1},
+			"level=INFO msg=first/web-3 first-event=1970-01-01T03:00:00.000Z last-event=1970-01-01T04:30:00.000Z\n",
+		},
+		{
+			"until",
+			logStreamQuery{needle: "web", until: at(90 * time.Minute), concurrency: 1},
+			"level=INFO msg=first/web-1 first-event=1970-01-01T01:00:00.000Z last-event=1970-01-01T02:00:00.000Z\n",
+		},
+		{
+			"during",
+			logStreamQuery{
+				needle: "web", since: at(3*time.Hour + 15*time.Minute), until: at(3*time.Hour + 45*time.Minute),
+				concurrency: 1,
+			},
+			"level=INFO msg=first/web-3 first-event=1970-01-01T03:00:00.000Z last-event=1970-01-01T04:30:00.000Z\n",
+		},
+		{
+			"by-last-event",
+			logStreamQuery{needle: "", since: at(5 * time.Hour), byLastEvent: true, concurrency: 1},
+			"level=INFO msg=first/web-2 first-event=1970-01-01T05:00:00.000Z last-event=1970-01-01T06:00:00.000Z\n" +
+				"level=INFO msg=first/web-3 first-event=1970-01-01T03:00:00.000Z last-event=1970-01-01T04:30:00.000Z\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			require.NoError(t, findLogStream(ctx, test.query, &logStreams{
+				logs: map[string][]types.LogStream{
+					"first": {
+						{
+							LogStreamName:       aws.String("web-1"),
+							FirstEventTimestamp: timestamp(time.Hour),
+							LastEventTimestamp:  timestamp(2 * time.Hour),
+						},
+						{
+							LogStreamName:       aws.String("web-2"),
+							FirstEventTimestamp: timestamp(5 * time.Hour),
+							LastEventTimestamp:  timestamp(6 * time.Hour),
+						},
+						{
+							LogStreamName:       aws.String("web-3"),
+							FirstEventTimestamp: timestamp(3 * time.Hour),
+							LastEventTimestamp:  timestamp(4*time.Hour + 30*time.Minute),
+						},
+						{
+							LogStreamName: aws.String("web-empty"),
+						},
+						{
+							LogStreamName:       aws.String("api-1"),
+							FirstEventTimestamp: timestamp(0),
+							LastEventTimestamp:  timestamp(time.Hour),
+						},
+					},
+				},
+			}))
+
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
 var _ logStreamLister = &logStreams{}
 
 type logStreams struct {
@@ -109,7 +286,7 @@ func (l *logStreams) DescribeLogGroups(
 	}
 
 	var groups []types.LogGroup
-	for name := range l.logs {
+	for _, name := range slices.Sorted(maps.Keys(l.logs)) {
 		groups = append(groups, types.LogGroup{LogGroupName: aws.String(name)})
 	}
 
@@ -121,7 +298,30 @@ func (l *logStreams) DescribeLogGroups(
 func (l *logStreams) DescribeLogStreams(
 	_ context.Context, input *cloudwatchlogs2.DescribeLogStreamsInput, _ ...func(*cloudwatchlogs2.Options),
 ) (*cloudwatchlogs2.DescribeLogStreamsOutput, error) {
-	streams := l.logs[aws.ToString(input.LogGroupName)]
+	if input.LogStreamNamePrefix != nil && input.OrderBy == types.OrderByLastEventTime {
+		return nil, errors.New("cannot order by last event time with a prefix")
+	}
+
+	var streams []types.LogStream
+	for _, stream := range l.logs[aws.ToString(input.LogGroupName)] {
+		if strings.HasPrefix(aws.ToString(stream.LogStreamName), aws.ToString(input.LogStreamNamePrefix)) {
+			streams = append(streams, stream)
+		}
+	}
+	if input.OrderBy == types.OrderByLastEventTime {
+		slices.SortStableFunc(streams, func(a, b types.LogStream) int {
+			return cmp.Compare(aws.ToInt64(a.LastEventTimestamp), aws.ToInt64(b.LastEventTimestamp))
+		})
+		if aws.ToBool(input.Descending) {
+			slices.Reverse(streams)
+		}
+	}
 
 	return &cloudwatchlogs2.DescribeLogStreamsOutput{LogStreams: streams}, nil
 }
+
+func (l *logStreams) ListTagsForResource(
+	_ context.Context, _ *cloudwatchlogs2.ListTagsForResourceInput, _ ...func(*cloudwatchlogs2.Options),
+) (*cloudwatchlogs2.ListTagsForResourceOutput, error) {
+	return nil, errors.New("unexpected call to list tags")
+}
@@ -12,6 +12,7 @@ import (
 func main() {
 	exeName := os.Args[0][strings.LastIndex(os.Args[0], string(os.PathSeparator))+1:]
 	logLevel := &logLevelFlag{level: slog.LevelInfo}
+	tags := &tagFiltersFlag{}
 	root := &cobra.Command{
 		Use: exeName,
 		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
@@ -22,6 +23,7 @@ func main() {
 					AddSource:   true,
 				}),
 			}))
+			ctx = contextWithTagFilters(ctx, tags.filters)
 
 			cmd.SetContext(ctx)
 			return nil
@@ -30,16 +32,24 @@ func main() {
 
 	root.AddCommand(
 		cloudfrontCmd(),
+		eipCmd(),
 		instanceCmd(),
+		logEventsCmd(),
 		logGroupCmd(),
 		logStreamCmd(),
+		privatelinkCmd(),
 		s3BucketCmd(),
+		s3ObjectCmd(),
+		securityGroupCmd(),
 		tagCmd(),
 		vpcCmd(),
 		vpcEndpointCmd(),
 		vpcEndpointServiceCmd(),
 	)
 	root.Flags().Var(logLevel, "log-level", "Level to log at")
+	root.PersistentFlags().Var(
+		tags, "tag", "Only find resources with this tag, optionally with a value that may contain * and ? wildcards",
+	)
 
 	if err := root.Execute(); err != nil {
 		panic(err)
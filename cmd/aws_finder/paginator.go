@@ -53,3 +53,24 @@ func concat[V any](seqs ...iter.Seq[V]) iter.Seq[V] {
 		}
 	}
 }
+
+// map2 converts each value of the sequence, stopping at the first error.
+func map2[V, R any](f func(V) (R, error), seq iter.Seq2[V, error]) iter.Seq2[R, error] {
+	var empty R
+	return func(yield func(R, error) bool) {
+		for v, err := range seq {
+			if err != nil {
+				yield(empty, err)
+				return
+			}
+			r, err := f(v)
+			if err != nil {
+				yield(empty, err)
+				return
+			}
+			if !yield(r, nil) {
+				return
+			}
+		}
+	}
+}
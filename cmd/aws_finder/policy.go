@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+const anyPrincipal = "*"
+
+// policyDocument is the subset of an IAM policy document, such as a bucket policy, needed to find who it grants
+// access to.
+type policyDocument struct {
+	Statement policyStatements
+}
+
+type policyStatement struct {
+	Effect    string
+	Principal policyPrincipal
+	Condition map[string]json.RawMessage
+}
+
+// conditional returns true if the statement only applies under some condition, such as the principal being in a
+// given organisation.
+func (s policyStatement) conditional() bool {
+	return len(s.Condition) != 0
+}
+
+// policyStatements can be either a single statement or a list of them.
+type policyStatements []policyStatement
+
+func (s *policyStatements) UnmarshalJSON(data []byte) error {
+	var statement policyStatement
+	if err := json.Unmarshal(data, &statement); err == nil {
+		*s = policyStatements{statement}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]policyStatement)(s))
+}
+
+// policyPrincipal is keyed by the type of principal, such as AWS or Service. A principal of "*" is treated the same as
+// {"AWS": "*"}.
+type policyPrincipal map[string]stringOrSlice
+
+func (p *policyPrincipal) UnmarshalJSON(data []byte) error {
+	var principal string
+	if err := json.Unmarshal(data, &principal); err == nil {
+		*p = policyPrincipal{"AWS": {principal}}
+		return nil
+	}
+	return json.Unmarshal(data, (*map[string]stringOrSlice)(p))
+}
+
+type stringOrSlice []string
+
+func (s *stringOrSlice) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*s = stringOrSlice{value}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(s))
+}
+
+func parsePolicy(policy string) (policyDocument, error) {
+	var document policyDocument
+	if err := json.Unmarshal([]byte(policy), &document); err != nil {
+		return policyDocument{}, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	return document, nil
+}
+
+// allowedPrincipals returns every principal the policy allows access to, ignoring any conditions.
+func (d policyDocument) allowedPrincipals() []string {
+	return d.principalsAllowedBy(func(policyStatement) bool { return true })
+}
+
+// principalsAllowedBy returns the principals allowed access by the statements accepted by the filter.
+func (d policyDocument) principalsAllowedBy(filter func(policyStatement) bool) []string {
+	var principals []string
+	for _, statement := range d.Statement {
+		if statement.Effect != "Allow" || !filter(statement) {
+			continue
+		}
+		for _, values := range statement.Principal {
+			for _, principal := range values {
+				if !slices.Contains(principals, principal) {
+					principals = append(principals, principal)
+				}
+			}
+		}
+	}
+	return principals
+}
+
+// principalMatches returns true if the principal from a policy grants access to the wanted principal, which is either
+// an account ID, an ARN or "*". Granting access to an account grants access to everything in it, but "*" only matches
+// if that is what is wanted.
+func principalMatches(principal, wanted string) bool {
+	if principal == wanted {
+		return true
+	}
+	if principal == anyPrincipal || wanted == anyPrincipal {
+		return false
+	}
+
+	account := principalAccount(principal)
+	if account == "" {
+		return false
+	}
+	if account == wanted {
+		return true
+	}
+	isAccount := principal == account || strings.HasSuffix(principal, ":root")
+	return isAccount && account == principalAccount(wanted)
+}
+
+// principalAccount returns the account ID of the principal, whether it is an ARN or just the ID.
+func principalAccount(principal string) string {
+	if parsed, err := arn.Parse(principal); err == nil {
+		return parsed.AccountID
+	}
+	if len(principal) == 12 && strings.Trim(principal, "0123456789") == "" { //nolint:mnd // length of an account ID
+		return principal
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyDocument_AllowedPrincipals(t *testing.T) {
+	var tests = []struct {
+		name     string
+		policy   string
+		expected []string
+	}{
+		{
+			"single-statement",
+			`{"Statement": {"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject"}}`,
+			[]string{"*"},
+		},
+		{
+			"statements",
+			`{"Version": "2012-10-17", "Statement": [
+				{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::123456789012:root"}},
+				{"Effect": "Allow", "Principal": {"AWS": ["210987654321", "arn:aws:iam::123456789012:root"]}},
+				{"Effect": "Allow", "Principal": {"Service": "logging.s3.amazonaws.com"}},
+				{"Effect": "Deny", "Principal": {"AWS": "arn:aws:iam::111111111111:role/denied"}}
+			]}`,
+			[]string{"arn:aws:iam::123456789012:root", "210987654321", "logging.s3.amazonaws.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			document, err := parsePolicy(test.policy)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, test.expected, document.allowedPrincipals())
+		})
+	}
+}
+
+func TestPolicyDocument_PrincipalsAllowedBy(t *testing.T) {
+	document, err := parsePolicy(`{"Statement": [
+		{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::123456789012:root"}},
+		{"Effect": "Allow", "Principal": "*", "Condition": {"StringEquals": {"aws:SourceVpce": "vpce-1"}}},
+		{"Effect": "Deny", "Principal": "*", "Condition": {"Bool": {"aws:SecureTransport": "false"}}}
+	]}`)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"*"}, document.principalsAllowedBy(policyStatement.conditional))
+	assert.Equal(t, []string{"arn:aws:iam::123456789012:root"}, document.principalsAllowedBy(
+		func(statement policyStatement) bool { return !statement.conditional() },
+	))
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	_, err := parsePolicy(`{"Statement": "nope"}`)
+	require.Error(t, err)
+}
+
+func TestPrincipalMatches(t *testing.T) {
+	var tests = []struct {
+		principal string
+		wanted    string
+		expected  bool
+	}{
+		{"*", "*", true},
+		{"*", "123456789012", false},
+		{"arn:aws:iam::123456789012:root", "*", false},
+		{"123456789012", "123456789012", true},
+		{"arn:aws:iam::123456789012:root", "123456789012", true},
+		{"arn:aws:iam::123456789012:role/app", "123456789012", true},
+		{"arn:aws:iam::123456789012:role/app", "210987654321", false},
+		{"arn:aws:iam::123456789012:root", "arn:aws:iam::123456789012:role/app", true},
+		{"123456789012", "arn:aws:iam::123456789012:role/app", true},
+		{"arn:aws:iam::123456789012:role/other", "arn:aws:iam::123456789012:role/app", false},
+		{"logging.s3.amazonaws.com", "123456789012", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.principal+"/"+test.wanted, func(t *testing.T) {
+			assert.Equal(t, test.expected, principalMatches(test.principal, test.wanted))
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/spf13/cobra"
+	"github.com/wjam/aws_finder/internal/finder"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func privatelinkCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "privatelink",
+		Short: "Work with PrivateLink services and the endpoints connecting to them",
+	}
+	cmd.AddCommand(privatelinkMapCmd())
+	return cmd
+}
+
+func privatelinkMapCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "map [needle]",
+		Short: "Map the endpoints connecting to each owned endpoint service, across every account",
+		Long: `Map the endpoints connecting to each owned endpoint service, across every account.
+
+Each connection is shown as the consumer account and VPC, the service and the provider account and load balancers.
+The needle and tags only apply to the services, and endpoints connecting to services outside of the accounts
+searched aren't shown. Consumers in accounts that can't be searched are still shown from the connections to the
+service, but without their VPC.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var needle string
+			if len(args) == 1 {
+				needle = args[0]
+			}
+			graph := &privatelinkGraph{}
+			tags := tagFiltersFromContext(cmd.Context())
+
+			if err := finder.SearchPerRegion(
+				cmd.Context(),
+				func(ctx context.Context, conf aws.Config) error {
+					return mapPrivatelink(ctx, needle, tags, graph, ec2.NewFromConfig(conf))
+				}); err != nil {
+				return err
+			}
+
+			graph.report(cmd.Context())
+			return nil
+		},
+	}
+}
+
+// mapPrivatelink adds the owned endpoint services and the endpoints in the account to the graph.
+func mapPrivatelink(
+	ctx context.Context, needle string, tags []tagFilter, graph *privatelinkGraph, client privatelinkLister,
+) error {
+	for svc, err := range ownedVpcEndpointServices(ctx, needle, tags, client) {
+		if err != nil {
+			return err
+		}
+
+		name := aws.ToString(svc.ServiceName)
+		loadBalancers := slices.Concat(svc.NetworkLoadBalancerArns, svc.GatewayLoadBalancerArns)
+		var provider string
+		if len(loadBalancers) != 0 {
+			provider = arnAccount(loadBalancers[0])
+		}
+		graph.addService(name, privatelinkProvider{
+			account:       provider,
+			loadBalancers: loadBalancers,
+		})
+
+		for connection, err := range vpcEndpointServiceConnections(ctx, client, aws.ToString(svc.ServiceId)) {
+			if err != nil {
+				return fmt.Errorf(
+					"failed to list connections to endpoint service %q: %w", aws.ToString(svc.ServiceId), err,
+				)
+			}
+			graph.addConsumer(name, aws.ToString(connection.VpcEndpointId), privatelinkConsumer{
+				account: aws.ToString(connection.VpcEndpointOwner),
+				state:   string(connection.VpcEndpointState),
+			})
+		}
+	}
+
+	for endpoint, err := range matchingVpcEndpoints(ctx, vpcEndpointQuery{}, client) {
+		if err != nil {
+			return err
+		}
+		graph.addConsumer(aws.ToString(endpoint.ServiceName), aws.ToString(endpoint.VpcEndpointId), privatelinkConsumer{
+			account: aws.ToString(endpoint.OwnerId),
+			vpc:     aws.ToString(endpoint.VpcId),
+			state:   string(endpoint.State),
+		})
+	}
+
+	return nil
+}
+
+type privatelinkProvider struct {
+	account       string
+	loadBalancers []string
+}
+
+type privatelinkConsumer struct {
+	account string
+	vpc     string
+	state   string
+}
+
+// merge fills in what is missing from the consumer, as the connection to a service doesn't include the VPC and the
+// endpoint may not be in an account that can be searched.
+func (c privatelinkConsumer) merge(other privatelinkConsumer) privatelinkConsumer {
+	if c.account == "" {
+		c.account = other.account
+	}
+	if c.vpc == "" {
+		c.vpc = other.vpc
+	}
+	if c.state == "" {
+		c.state = other.state
+	}
+	return c
+}
+
+// privatelinkGraph joins endpoint services to the endpoints connecting to them, shared between every profile and
+// region.
+type privatelinkGraph struct {
+	lock      sync.Mutex
+	providers map[string]privatelinkProvider
+	// consumers are keyed by service name and then endpoint ID.
+	consumers map[string]map[string]privatelinkConsumer
+}
+
+func (g *privatelinkGraph) addService(service string, provider privatelinkProvider) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.providers == nil {
+		g.providers = map[string]privatelinkProvider{}
+	}
+	g.providers[service] = provider
+}
+
+func (g *privatelinkGraph) addConsumer(service, endpoint string, consumer privatelinkConsumer) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if g.consumers == nil {
+		g.consumers = map[string]map[string]privatelinkConsumer{}
+	}
+	if g.consumers[service] == nil {
+		g.consumers[service] = map[string]privatelinkConsumer{}
+	}
+	g.consumers[service][endpoint] = consumer.merge(g.consumers[service][endpoint])
+}
+
+func (g *privatelinkGraph) report(ctx context.Context) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for _, service := range slices.Sorted(maps.Keys(g.providers)) {
+		provider := g.providers[service]
+		providerAttrs := []any{
+			slog.String("provider-account", provider.account),
+			slog.String("load-balancers", strings.Join(provider.loadBalancers, ",")),
+		}
+
+		consumers := g.consumers[service]
+		if len(consumers) == 0 {
+			log.Logger(ctx).InfoContext(ctx, service, providerAttrs...)
+			continue
+		}
+
+		for _, endpoint := range slices.Sorted(maps.Keys(consumers)) {
+			consumer := consumers[endpoint]
+			log.Logger(ctx).InfoContext(
+				ctx,
+				service,
+				append([]any{
+					slog.String("consumer-account", consumer.account),
+					slog.String("consumer-vpc", consumer.vpc),
+					slog.String("endpoint", endpoint),
+					slog.String("state", consumer.state),
+				}, providerAttrs...)...,
+			)
+		}
+	}
+}
+
+type privatelinkLister interface {
+	ec2.DescribeVpcEndpointsAPIClient
+	vpcEndpointServiceOwner
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func TestMapPrivatelink(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	const service = "com.amazonaws.vpce.eu-west-1.vpce-svc-1"
+	const loadBalancer = "arn:aws:elasticloadbalancing:eu-west-1:111111111111:loadbalancer/net/payments/1"
+	graph := &privatelinkGraph{}
+
+	consumer := &privatelinkAccount{
+		vpcEndpointLister: &vpcEndpointLister{
+			endpoints: [][]types.VpcEndpoint{
+				{
+					{
+						VpcEndpointId: aws.String("vpce-a"),
+						OwnerId:       aws.String("222222222222"),
+						VpcId:         aws.String("vpc-b"),
+						ServiceName:   aws.String(service),
+						State:         "available",
+					},
+					{
+						VpcEndpointId: aws.String("vpce-s3"),
+						OwnerId:       aws.String("222222222222"),
+						VpcId:         aws.String("vpc-b"),
+						ServiceName:   aws.String("com.amazonaws.eu-west-1.s3"),
+						State:         "available",
+					},
+				},
+			},
+		},
+		ownedEndpointServices: &ownedEndpointServices{},
+	}
+	require.NoError(t, mapPrivatelink(ctx, "", nil, graph, consumer))
+
+	provider := &privatelinkAccount{
+		vpcEndpointLister: &vpcEndpointLister{endpoints: [][]types.VpcEndpoint{{}}},
+		ownedEndpointServices: &ownedEndpointServices{
+			services: []types.ServiceConfiguration{
+				{
+					ServiceId:               aws.String("vpce-svc-1"),
+					ServiceName:             aws.String(service),
+					NetworkLoadBalancerArns: []string{loadBalancer},
+				},
+				{
+					ServiceId:   aws.String("vpce-svc-2"),
+					ServiceName: aws.String("com.amazonaws.vpce.eu-west-1.vpce-svc-2"),
+				},
+			},
+			connections: map[string][]types.VpcEndpointConnection{
+				"vpce-svc-1": {
+					{
+						VpcEndpointId:    aws.String("vpce-a"),
+						VpcEndpointOwner: aws.String("222222222222"),
+						VpcEndpointState: "available",
+					},
+					{
+						VpcEndpointId:    aws.String("vpce-c"),
+						VpcEndpointOwner: aws.String("333333333333"),
+						VpcEndpointState: "pendingAcceptance",
+					},
+				},
+			},
+		},
+	}
+	require.NoError(t, mapPrivatelink(ctx, "", nil, graph, provider))
+
+	graph.report(ctx)
+
+	assert.Equal(
+		t,
+		"level=INFO msg="+service+" consumer-account=222222222222 consumer-vpc=vpc-b endpoint=vpce-a "+
+			"state=available provider-account=111111111111 load-balancers="+loadBalancer+"\n"+
+			"level=INFO msg="+service+" consumer-account=333333333333 consumer-vpc=\"\" endpoint=vpce-c "+
+			"state=pendingAcceptance provider-account=111111111111 load-balancers="+loadBalancer+"\n"+
+			"level=INFO msg=com.amazonaws.vpce.eu-west-1.vpce-svc-2 provider-account=\"\" load-balancers=\"\"\n",
+		buf.String(),
+	)
+}
+
+var _ privatelinkLister = &privatelinkAccount{}
+
+type privatelinkAccount struct {
+	*vpcEndpointLister
+	*ownedEndpointServices
+}
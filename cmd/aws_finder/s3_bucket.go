@@ -2,66 +2,202 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"iter"
 	"log/slog"
+	"slices"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/spf13/cobra"
 	"github.com/wjam/aws_finder/internal/finder"
 	"github.com/wjam/aws_finder/internal/log"
 )
 
 func s3BucketCmd() *cobra.Command {
-	return &cobra.Command{
+	var query s3BucketQuery
+	cmd := &cobra.Command{
 		Use:   "s3_bucket [needle]",
 		Short: "Find an S3 bucket by name",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := query.config.validate(); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				query.needle = args[0]
+			}
+			query.tags = tagFiltersFromContext(cmd.Context())
 			return finder.SearchPerProfile(
 				cmd.Context(),
 				func(ctx context.Context, conf aws.Config) error {
-					return findS3Bucket(ctx, args[0], s3.NewFromConfig(conf))
+					return findS3Bucket(ctx, query, s3.NewFromConfig(conf))
 				})
 		},
 	}
+	cmd.Flags().StringVar(&query.prefix, "prefix", "", "Only find buckets whose name starts with this prefix")
+	cmd.Flags().StringVar(&query.region, "bucket-region", "", "Only find buckets in this region")
+	addS3BucketConfigFlags(cmd.Flags(), &query.config)
+	return cmd
 }
 
-func findS3Bucket(ctx context.Context, needle string, client s3Lister) error {
-	buckets, err := client.ListBuckets(ctx, nil)
-	if err != nil {
-		return err
+type s3BucketQuery struct {
+	needle string
+	prefix string
+	region string
+	tags   []tagFilter
+	config s3BucketConfigQuery
+}
+
+func findS3Bucket(ctx context.Context, query s3BucketQuery, client s3Lister) error {
+	input := &s3.ListBucketsInput{}
+	if query.prefix != "" {
+		input.Prefix = aws.String(query.prefix)
+	}
+	if query.region != "" {
+		input.BucketRegion = aws.String(query.region)
 	}
+	checks := query.config.checks()
 
-	for _, bucket := range buckets.Buckets {
-		if strings.Contains(aws.ToString(bucket.Name), needle) {
-			location, err := client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{Bucket: bucket.Name})
-			if err != nil {
-				return fmt.Errorf("failed to query bucket %q for location: %w", aws.ToString(bucket.Name), err)
-			}
+	for bucket, err := range s3Buckets(ctx, client, input) {
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(aws.ToString(bucket.Name), query.needle) {
+			continue
+		}
 
-			if location.LocationConstraint == types.BucketLocationConstraintEu {
-				location.LocationConstraint = types.BucketLocationConstraintEuWest1
-			}
-			log.Logger(ctx).
-				InfoContext(
-					ctx,
-					aws.ToString(bucket.Name),
-					slog.String("location", string(location.LocationConstraint)),
-				)
+		matched, err := s3BucketMatchesTags(ctx, query.tags, client, bucket)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
 		}
+
+		matched, attrs, err := s3BucketMatchesConfig(ctx, checks, client, bucket)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		log.Logger(ctx).
+			InfoContext(
+				ctx,
+				aws.ToString(bucket.Name),
+				append([]any{slog.String("location", s3BucketRegion(bucket))}, attrs...)...,
+			)
 	}
 
 	return nil
 }
 
+// s3BucketsPerPage is the most buckets ListBuckets returns at once. ListBuckets must be paginated for accounts allowed
+// more than 10,000 buckets.
+const s3BucketsPerPage = 1000
+
+func s3Buckets(
+	ctx context.Context, client s3.ListBucketsAPIClient, input *s3.ListBucketsInput,
+) iter.Seq2[types.Bucket, error] {
+	paginated := *input
+	paginated.MaxBuckets = aws.Int32(s3BucketsPerPage)
+	pages := s3.NewListBucketsPaginator(client, &paginated)
+	return paginatorToSeq(ctx, pages, func(r *s3.ListBucketsOutput) iter.Seq[types.Bucket] {
+		return slices.Values(r.Buckets)
+	})
+}
+
+// s3BucketRegion returns the region of the bucket, as returned by ListBuckets, where a blank region is us-east-1.
+func s3BucketRegion(bucket types.Bucket) string {
+	if region := aws.ToString(bucket.BucketRegion); region != "" {
+		return region
+	}
+	return "us-east-1"
+}
+
+// s3BucketRegionOption sends the request to the region of the bucket, as S3 rejects requests to other regions.
+func s3BucketRegionOption(bucket types.Bucket) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.Region = s3BucketRegion(bucket)
+	}
+}
+
+// s3BucketMatchesTags checks the tags of the bucket, which has to be queried in the region the bucket is in.
+func s3BucketMatchesTags(ctx context.Context, tags []tagFilter, client s3TagLister, bucket types.Bucket) (bool, error) {
+	if len(tags) == 0 {
+		return true, nil
+	}
+
+	bucketTags, err := s3BucketTags(ctx, client, bucket)
+	if err != nil {
+		return false, err
+	}
+	return matchesTagFilters(tags, bucketTags), nil
+}
+
+func s3BucketTags(ctx context.Context, client s3TagLister, bucket types.Bucket) (map[string]string, error) {
+	output, err := client.GetBucketTagging(
+		ctx, &s3.GetBucketTaggingInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket),
+	)
+	if isAPIError(err, "NoSuchTagSet") {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bucket %q for tags: %w", aws.ToString(bucket.Name), err)
+	}
+
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+func isAPIError(err error, code string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
+
+type s3TagLister interface {
+	s3.ListBucketsAPIClient
+	GetBucketTagging(
+		ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options),
+	) (*s3.GetBucketTaggingOutput, error)
+}
+
 type s3Lister interface {
-	ListBuckets(
-		ctx context.Context, params *s3.ListBucketsInput, optFns ...func(*s3.Options),
-	) (*s3.ListBucketsOutput, error)
-	GetBucketLocation(
-		ctx context.Context, params *s3.GetBucketLocationInput, optFns ...func(*s3.Options),
-	) (*s3.GetBucketLocationOutput, error)
+	s3TagLister
+	GetPublicAccessBlock(
+		ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options),
+	) (*s3.GetPublicAccessBlockOutput, error)
+	GetBucketEncryption(
+		ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options),
+	) (*s3.GetBucketEncryptionOutput, error)
+	GetBucketVersioning(
+		ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options),
+	) (*s3.GetBucketVersioningOutput, error)
+	GetBucketOwnershipControls(
+		ctx context.Context, params *s3.GetBucketOwnershipControlsInput, optFns ...func(*s3.Options),
+	) (*s3.GetBucketOwnershipControlsOutput, error)
+	GetBucketWebsite(
+		ctx context.Context, params *s3.GetBucketWebsiteInput, optFns ...func(*s3.Options),
+	) (*s3.GetBucketWebsiteOutput, error)
+	GetBucketReplication(
+		ctx context.Context, params *s3.GetBucketReplicationInput, optFns ...func(*s3.Options),
+	) (*s3.GetBucketReplicationOutput, error)
+	GetBucketLogging(
+		ctx context.Context, params *s3.GetBucketLoggingInput, optFns ...func(*s3.Options),
+	) (*s3.GetBucketLoggingOutput, error)
+	GetBucketPolicy(
+		ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options),
+	) (*s3.GetBucketPolicyOutput, error)
+	GetBucketAcl(
+		ctx context.Context, params *s3.GetBucketAclInput, optFns ...func(*s3.Options),
+	) (*s3.GetBucketAclOutput, error)
 }
@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/spf13/pflag"
+)
+
+const (
+	publicAccessBlocked   = "blocked"
+	publicAccessPartial   = "partial"
+	publicAccessUnblocked = "unblocked"
+
+	versioningDisabled = "Disabled"
+)
+
+// s3BucketConfigQuery finds buckets by their configuration, each of which needs its own call per bucket so is only
+// queried when asked for.
+type s3BucketConfigQuery struct {
+	publicAccessBlock string
+	encryption        string
+	kmsKey            string
+	versioning        string
+	objectOwnership   string
+	website           bool
+	replication       bool
+	loggingTarget     string
+	principal         string
+}
+
+func addS3BucketConfigFlags(flags *pflag.FlagSet, query *s3BucketConfigQuery) {
+	flags.StringVar(
+		&query.publicAccessBlock,
+		"public-access-block",
+		"",
+		fmt.Sprintf(
+			"Only find buckets where public access is %s",
+			strings.Join(s3PublicAccessBlockStates(), ", "),
+		),
+	)
+	flags.StringVar(
+		&query.encryption,
+		"encryption",
+		"",
+		fmt.Sprintf(
+			"Only find buckets encrypted by default with this algorithm (%s)",
+			strings.Join(enumStrings(types.ServerSideEncryption("").Values()), ", "),
+		),
+	)
+	flags.StringVar(&query.kmsKey, "kms-key", "", "Only find buckets encrypted by default with this KMS key")
+	flags.StringVar(
+		&query.versioning,
+		"versioning",
+		"",
+		fmt.Sprintf("Only find buckets with this versioning status (%s)", strings.Join(s3VersioningStates(), ", ")),
+	)
+	flags.StringVar(
+		&query.objectOwnership,
+		"object-ownership",
+		"",
+		fmt.Sprintf(
+			"Only find buckets with this object ownership (%s)",
+			strings.Join(enumStrings(types.ObjectOwnership("").Values()), ", "),
+		),
+	)
+	flags.BoolVar(&query.website, "website", false, "Only find buckets hosting a website")
+	flags.BoolVar(&query.replication, "replication", false, "Only find buckets replicating to another bucket")
+	flags.StringVar(&query.loggingTarget, "logging-target", "", "Only find buckets logging to this bucket")
+	flags.StringVar(
+		&query.principal,
+		"principal",
+		"",
+		"Only find buckets whose policy or ACL grants access to this account ID, ARN or canonical user ID, or * for "+
+			"public access",
+	)
+}
+
+func (q s3BucketConfigQuery) validate() error {
+	if q.publicAccessBlock != "" && !slices.Contains(s3PublicAccessBlockStates(), q.publicAccessBlock) {
+		return fmt.Errorf("unknown public access block state %q", q.publicAccessBlock)
+	}
+	if q.encryption != "" && !slices.Contains(enumStrings(types.ServerSideEncryption("").Values()), q.encryption) {
+		return fmt.Errorf("unknown encryption algorithm %q", q.encryption)
+	}
+	if q.versioning != "" && !slices.Contains(s3VersioningStates(), q.versioning) {
+		return fmt.Errorf("unknown versioning status %q", q.versioning)
+	}
+	if q.objectOwnership != "" && !slices.Contains(enumStrings(types.ObjectOwnership("").Values()), q.objectOwnership) {
+		return fmt.Errorf("unknown object ownership %q", q.objectOwnership)
+	}
+	return nil
+}
+
+// s3BucketCheck returns whether the bucket matches, along with the configuration it checked so it can be logged.
+type s3BucketCheck func(ctx context.Context, client s3Lister, bucket types.Bucket) (bool, []any, error)
+
+func (q s3BucketConfigQuery) checks() []s3BucketCheck {
+	var checks []s3BucketCheck
+	if q.publicAccessBlock != "" {
+		checks = append(checks, q.checkPublicAccessBlock)
+	}
+	if q.encryption != "" || q.kmsKey != "" {
+		checks = append(checks, q.checkEncryption)
+	}
+	if q.versioning != "" {
+		checks = append(checks, q.checkVersioning)
+	}
+	if q.objectOwnership != "" {
+		checks = append(checks, q.checkObjectOwnership)
+	}
+	if q.website {
+		checks = append(checks, checkS3Website)
+	}
+	if q.replication {
+		checks = append(checks, checkS3Replication)
+	}
+	if q.loggingTarget != "" {
+		checks = append(checks, q.checkLoggingTarget)
+	}
+	if q.principal != "" {
+		checks = append(checks, q.checkPrincipal)
+	}
+	return checks
+}
+
+func s3BucketMatchesConfig(
+	ctx context.Context, checks []s3BucketCheck, client s3Lister, bucket types.Bucket,
+) (bool, []any, error) {
+	var attrs []any
+	for _, check := range checks {
+		matched, checkAttrs, err := check(ctx, client, bucket)
+		if err != nil {
+			return false, nil, fmt.Errorf(
+				"failed to query bucket %q for configuration: %w", aws.ToString(bucket.Name), err,
+			)
+		}
+		if !matched {
+			return false, nil, nil
+		}
+		attrs = append(attrs, checkAttrs...)
+	}
+	return true, attrs, nil
+}
+
+// checkPublicAccessBlock only considers the bucket's own settings, not those of the account.
+func (q s3BucketConfigQuery) checkPublicAccessBlock(
+	ctx context.Context, client s3Lister, bucket types.Bucket,
+) (bool, []any, error) {
+	output, err := client.GetPublicAccessBlock(
+		ctx, &s3.GetPublicAccessBlockInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket),
+	)
+	state := publicAccessUnblocked
+	switch {
+	case isAPIError(err, "NoSuchPublicAccessBlockConfiguration"):
+	case err != nil:
+		return false, nil, err
+	default:
+		config := output.PublicAccessBlockConfiguration
+		blocked := 0
+		for _, setting := range []*bool{
+			config.BlockPublicAcls, config.IgnorePublicAcls, config.BlockPublicPolicy, config.RestrictPublicBuckets,
+		} {
+			if aws.ToBool(setting) {
+				blocked++
+			}
+		}
+		switch blocked {
+		case 0:
+		case 4: //nolint:mnd // every setting
+			state = publicAccessBlocked
+		default:
+			state = publicAccessPartial
+		}
+	}
+
+	return state == q.publicAccessBlock, []any{slog.String("public-access-block", state)}, nil
+}
+
+func (q s3BucketConfigQuery) checkEncryption(
+	ctx context.Context, client s3Lister, bucket types.Bucket,
+) (bool, []any, error) {
+	output, err := client.GetBucketEncryption(
+		ctx, &s3.GetBucketEncryptionInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket),
+	)
+	if isAPIError(err, "ServerSideEncryptionConfigurationNotFoundError") {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
+	for _, rule := range output.ServerSideEncryptionConfiguration.Rules {
+		encryption := rule.ApplyServerSideEncryptionByDefault
+		if encryption == nil {
+			continue
+		}
+		if q.encryption != "" && string(encryption.SSEAlgorithm) != q.encryption {
+			continue
+		}
+		if q.kmsKey != "" && !kmsKeyMatches(aws.ToString(encryption.KMSMasterKeyID), q.kmsKey) {
+			continue
+		}
+
+		attrs := []any{slog.String("encryption", string(encryption.SSEAlgorithm))}
+		if encryption.KMSMasterKeyID != nil {
+			attrs = append(attrs, slog.String("kms-key", aws.ToString(encryption.KMSMasterKeyID)))
+		}
+		return true, attrs, nil
+	}
+	return false, nil, nil
+}
+
+// kmsKeyMatches compares keys that may be given as either an ID or an ARN.
+func kmsKeyMatches(key, wanted string) bool {
+	return key == wanted || strings.HasSuffix(key, ":key/"+wanted) || strings.HasSuffix(wanted, ":key/"+key)
+}
+
+func (q s3BucketConfigQuery) checkVersioning(
+	ctx context.Context, client s3Lister, bucket types.Bucket,
+) (bool, []any, error) {
+	output, err := client.GetBucketVersioning(
+		ctx, &s3.GetBucketVersioningInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket),
+	)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Buckets that have never had versioning enabled don't have a status
+	status := string(output.Status)
+	if status == "" {
+		status = versioningDisabled
+	}
+	return status == q.versioning, []any{slog.String("versioning", status)}, nil
+}
+
+func (q s3BucketConfigQuery) checkObjectOwnership(
+	ctx context.Context, client s3Lister, bucket types.Bucket,
+) (bool, []any, error) {
+	output, err := client.GetBucketOwnershipControls(
+		ctx, &s3.GetBucketOwnershipControlsInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket),
+	)
+	// Buckets without ownership controls behave as the object writer owns the objects
+	ownership := string(types.ObjectOwnershipObjectWriter)
+	switch {
+	case isAPIError(err, "OwnershipControlsNotFoundError"):
+	case err != nil:
+		return false, nil, err
+	case len(output.OwnershipControls.Rules) != 0:
+		ownership = string(output.OwnershipControls.Rules[0].ObjectOwnership)
+	}
+
+	return ownership == q.objectOwnership, []any{slog.String("object-ownership", ownership)}, nil
+}
+
+func checkS3Website(ctx context.Context, client s3Lister, bucket types.Bucket) (bool, []any, error) {
+	_, err := client.GetBucketWebsite(ctx, &s3.GetBucketWebsiteInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket))
+	if isAPIError(err, "NoSuchWebsiteConfiguration") {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	return true, []any{slog.Bool("website", true)}, nil
+}
+
+func checkS3Replication(ctx context.Context, client s3Lister, bucket types.Bucket) (bool, []any, error) {
+	output, err := client.GetBucketReplication(
+		ctx, &s3.GetBucketReplicationInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket),
+	)
+	if isAPIError(err, "ReplicationConfigurationNotFoundError") {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+
+	var destinations []string
+	for _, rule := range output.ReplicationConfiguration.Rules {
+		if rule.Destination != nil && !slices.Contains(destinations, aws.ToString(rule.Destination.Bucket)) {
+			destinations = append(destinations, aws.ToString(rule.Destination.Bucket))
+		}
+	}
+	return true, []any{slog.String("replication", strings.Join(destinations, ","))}, nil
+}
+
+func (q s3BucketConfigQuery) checkLoggingTarget(
+	ctx context.Context, client s3Lister, bucket types.Bucket,
+) (bool, []any, error) {
+	output, err := client.GetBucketLogging(
+		ctx, &s3.GetBucketLoggingInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket),
+	)
+	if err != nil {
+		return false, nil, err
+	}
+	if output.LoggingEnabled == nil {
+		return false, nil, nil
+	}
+
+	target := aws.ToString(output.LoggingEnabled.TargetBucket)
+	return target == q.loggingTarget, []any{slog.String("logging-target", target)}, nil
+}
+
+// checkPrincipal looks for the principal in both the bucket policy and ACL. Access granted to everyone, rather than to
+// the principal, is marked with "*".
+func (q s3BucketConfigQuery) checkPrincipal(
+	ctx context.Context, client s3Lister, bucket types.Bucket,
+) (bool, []any, error) {
+	var grantedBy []string
+
+	policy, err := client.GetBucketPolicy(
+		ctx, &s3.GetBucketPolicyInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket),
+	)
+	switch {
+	case isAPIError(err, "NoSuchBucketPolicy"):
+	case err != nil:
+		return false, nil, err
+	default:
+		document, err := parsePolicy(aws.ToString(policy.Policy))
+		if err != nil {
+			return false, nil, err
+		}
+		if marker := q.policyGrant(document); marker != "" {
+			grantedBy = append(grantedBy, marker)
+		}
+	}
+
+	acl, err := client.GetBucketAcl(ctx, &s3.GetBucketAclInput{Bucket: bucket.Name}, s3BucketRegionOption(bucket))
+	if err != nil {
+		return false, nil, err
+	}
+	switch {
+	case slices.ContainsFunc(acl.Grants, func(grant types.Grant) bool {
+		return s3GrantMatches(grant, acl.Owner, q.principal)
+	}):
+		grantedBy = append(grantedBy, "acl")
+	case slices.ContainsFunc(acl.Grants, func(grant types.Grant) bool {
+		return s3GrantMatches(grant, acl.Owner, anyPrincipal)
+	}):
+		grantedBy = append(grantedBy, "acl:*")
+	}
+
+	if len(grantedBy) == 0 {
+		return false, nil, nil
+	}
+	return true, []any{slog.String("granted-by", strings.Join(grantedBy, ","))}, nil
+}
+
+// policyGrant returns how the bucket policy grants access to the principal, if at all. Access only granted under some
+// condition, such as the principal being in a given organisation, is marked as conditional as the condition usually
+// excludes most accounts.
+func (q s3BucketConfigQuery) policyGrant(document policyDocument) string {
+	unconditional := document.principalsAllowedBy(func(statement policyStatement) bool {
+		return !statement.conditional()
+	})
+	conditional := document.principalsAllowedBy(policyStatement.conditional)
+	matches := func(principal string) bool {
+		return principalMatches(principal, q.principal)
+	}
+
+	switch {
+	case slices.ContainsFunc(unconditional, matches):
+		return "policy"
+	case slices.Contains(unconditional, anyPrincipal):
+		return "policy:*"
+	case slices.ContainsFunc(conditional, matches):
+		return "policy:conditional"
+	case slices.Contains(conditional, anyPrincipal):
+		return "policy:*conditional"
+	default:
+		return ""
+	}
+}
+
+// s3GrantMatches checks whether an ACL grant, other than to the bucket owner, is to the principal. ACLs only refer to
+// canonical user IDs, so account IDs and ARNs never match, whereas * matches grants to all or any authenticated users.
+func s3GrantMatches(grant types.Grant, owner *types.Owner, principal string) bool {
+	grantee := grant.Grantee
+	if grantee == nil {
+		return false
+	}
+	if owner != nil && grantee.ID != nil && aws.ToString(grantee.ID) == aws.ToString(owner.ID) {
+		return false
+	}
+	if principal == anyPrincipal {
+		return slices.Contains(s3PublicGroups(), aws.ToString(grantee.URI))
+	}
+	return aws.ToString(grantee.ID) == principal || aws.ToString(grantee.EmailAddress) == principal
+}
+
+func s3PublicGroups() []string {
+	return []string{
+		"http://acs.amazonaws.com/groups/global/AllUsers",
+		"http://acs.amazonaws.com/groups/global/AuthenticatedUsers",
+	}
+}
+
+func s3PublicAccessBlockStates() []string {
+	return []string{publicAccessBlocked, publicAccessPartial, publicAccessUnblocked}
+}
+
+func s3VersioningStates() []string {
+	return append(enumStrings(types.BucketVersioningStatus("").Values()), versioningDisabled)
+}
@@ -4,19 +4,73 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/wjam/aws_finder/internal/log"
 )
 
 func TestFindS3Bucket(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    s3BucketQuery
+		expected string
+	}{
+		{
+			"needle",
+			s3BucketQuery{needle: "find"},
+			"level=INFO msg=\"find me\" location=eu-west-2\n" +
+				"level=INFO msg=\"find me too\" location=us-east-1\n",
+		},
+		{
+			"prefix",
+			s3BucketQuery{prefix: "ba"},
+			"level=INFO msg=bar location=eu-west-1\n",
+		},
+		{
+			"region",
+			s3BucketQuery{needle: "find", region: "eu-west-2"},
+			"level=INFO msg=\"find me\" location=eu-west-2\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			require.NoError(t, findS3Bucket(ctx, test.query, &buckets{
+				buckets: []types.Bucket{
+					{Name: aws.String("foo"), BucketRegion: aws.String("eu-west-1")},
+					{Name: aws.String("bar"), BucketRegion: aws.String("eu-west-1")},
+					{Name: aws.String("find me"), BucketRegion: aws.String("eu-west-2")},
+					{Name: aws.String("find me too")},
+				},
+			}))
+
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestFindS3Bucket_Tags(t *testing.T) {
 	var buf bytes.Buffer
 
 	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
@@ -26,56 +80,441 @@ func TestFindS3Bucket(t *testing.T) {
 		}),
 	}))
 
-	require.NoError(t, findS3Bucket(ctx, "find", &buckets{
+	require.NoError(t, findS3Bucket(ctx, s3BucketQuery{needle: "find", tags: []tagFilter{{key: "owner"}}}, &buckets{
 		buckets: []types.Bucket{
 			{
-				Name: aws.String("foo"),
-			},
-			{
-				Name: aws.String("bar"),
+				Name:         aws.String("find untagged"),
+				BucketRegion: aws.String("eu-west-2"),
 			},
 			{
 				Name: aws.String("find me"),
 			},
 		},
-		bucketLocation: map[string]types.BucketLocationConstraint{
-			"foo":     "bip",
-			"bar":     "baz",
-			"find me": "found",
+		bucketTags: map[string][]types.Tag{
+			"find me": {
+				{
+					Key:   aws.String("owner"),
+					Value: aws.String("someone"),
+				},
+			},
 		},
 	}))
 
-	assert.Equal(t, "level=INFO msg=\"find me\" location=found\n", buf.String())
+	assert.Equal(t, "level=INFO msg=\"find me\" location=us-east-1\n", buf.String())
+}
+
+func TestFindS3Bucket_Config(t *testing.T) {
+	var tests = []struct {
+		name     string
+		config   s3BucketConfigQuery
+		expected string
+	}{
+		{
+			"public-access-blocked",
+			s3BucketConfigQuery{publicAccessBlock: "blocked"},
+			"level=INFO msg=locked location=eu-west-1 public-access-block=blocked\n",
+		},
+		{
+			"public-access-unblocked",
+			s3BucketConfigQuery{publicAccessBlock: "unblocked"},
+			"level=INFO msg=website location=us-east-1 public-access-block=unblocked\n",
+		},
+		{
+			"public-access-partial",
+			s3BucketConfigQuery{publicAccessBlock: "partial"},
+			"level=INFO msg=logged location=eu-west-2 public-access-block=partial\n",
+		},
+		{
+			"kms-key-id",
+			s3BucketConfigQuery{kmsKey: "1234abcd"},
+			"level=INFO msg=locked location=eu-west-1 encryption=aws:kms " +
+				"kms-key=arn:aws:kms:eu-west-1:123456789012:key/1234abcd\n",
+		},
+		{
+			"kms-key-arn",
+			s3BucketConfigQuery{encryption: "aws:kms", kmsKey: "arn:aws:kms:eu-west-1:123456789012:key/1234abcd"},
+			"level=INFO msg=locked location=eu-west-1 encryption=aws:kms " +
+				"kms-key=arn:aws:kms:eu-west-1:123456789012:key/1234abcd\n",
+		},
+		{
+			"encryption",
+			s3BucketConfigQuery{encryption: "AES256"},
+			"level=INFO msg=logged location=eu-west-2 encryption=AES256\n",
+		},
+		{
+			"versioning-disabled",
+			s3BucketConfigQuery{versioning: "Disabled"},
+			"level=INFO msg=logged location=eu-west-2 versioning=Disabled\n" +
+				"level=INFO msg=website location=us-east-1 versioning=Disabled\n",
+		},
+		{
+			"object-ownership",
+			s3BucketConfigQuery{objectOwnership: "ObjectWriter"},
+			"level=INFO msg=logged location=eu-west-2 object-ownership=ObjectWriter\n" +
+				"level=INFO msg=website location=us-east-1 object-ownership=ObjectWriter\n",
+		},
+		{
+			"website-and-versioning",
+			s3BucketConfigQuery{website: true, versioning: "Disabled"},
+			"level=INFO msg=website location=us-east-1 versioning=Disabled website=true\n",
+		},
+		{
+			"replication",
+			s3BucketConfigQuery{replication: true},
+			"level=INFO msg=locked location=eu-west-1 replication=replica\n",
+		},
+		{
+			"logging-target",
+			s3BucketConfigQuery{loggingTarget: "logs"},
+			"level=INFO msg=logged location=eu-west-2 logging-target=logs\n",
+		},
+		{
+			"principal-account",
+			s3BucketConfigQuery{principal: "210987654321"},
+			"level=INFO msg=locked location=eu-west-1 granted-by=policy\n" +
+				"level=INFO msg=logged location=eu-west-2 granted-by=policy:*conditional\n" +
+				"level=INFO msg=website location=us-east-1 granted-by=policy:*,acl:*\n",
+		},
+		{
+			"principal-role",
+			s3BucketConfigQuery{principal: "arn:aws:iam::210987654321:role/reader"},
+			"level=INFO msg=locked location=eu-west-1 granted-by=policy\n" +
+				"level=INFO msg=logged location=eu-west-2 granted-by=policy:*conditional\n" +
+				"level=INFO msg=website location=us-east-1 granted-by=policy:*,acl:*\n",
+		},
+		{
+			"principal-canonical-user",
+			s3BucketConfigQuery{principal: "external"},
+			"level=INFO msg=logged location=eu-west-2 granted-by=policy:*conditional,acl\n" +
+				"level=INFO msg=website location=us-east-1 granted-by=policy:*,acl:*\n",
+		},
+		{
+			"principal-public",
+			s3BucketConfigQuery{principal: "*"},
+			"level=INFO msg=logged location=eu-west-2 granted-by=policy:conditional\n" +
+				"level=INFO msg=website location=us-east-1 granted-by=policy,acl\n",
+		},
+		{
+			"principal-owner",
+			s3BucketConfigQuery{principal: "owner"},
+			"level=INFO msg=logged location=eu-west-2 granted-by=policy:*conditional\n" +
+				"level=INFO msg=website location=us-east-1 granted-by=policy:*,acl:*\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			require.NoError(t, test.config.validate())
+			require.NoError(t, findS3Bucket(ctx, s3BucketQuery{config: test.config}, &buckets{
+				buckets: []types.Bucket{
+					{Name: aws.String("locked"), BucketRegion: aws.String("eu-west-1")},
+					{Name: aws.String("logged"), BucketRegion: aws.String("eu-west-2")},
+					{Name: aws.String("website")},
+				},
+				publicAccessBlock: map[string]*types.PublicAccessBlockConfiguration{
+					"locked": {
+						BlockPublicAcls:       aws.Bool(true),
+						IgnorePublicAcls:      aws.Bool(true),
+						BlockPublicPolicy:     aws.Bool(true),
+						RestrictPublicBuckets: aws.Bool(true),
+					},
+					"logged": {BlockPublicAcls: aws.Bool(true)},
+				},
+				encryption: map[string]*types.ServerSideEncryptionByDefault{
+					"locked": {
+						SSEAlgorithm:   types.ServerSideEncryptionAwsKms,
+						KMSMasterKeyID: aws.String("arn:aws:kms:eu-west-1:123456789012:key/1234abcd"),
+					},
+					"logged": {SSEAlgorithm: types.ServerSideEncryptionAes256},
+				},
+				versioning:  map[string]types.BucketVersioningStatus{"locked": types.BucketVersioningStatusEnabled},
+				ownership:   map[string]types.ObjectOwnership{"locked": types.ObjectOwnershipBucketOwnerEnforced},
+				websites:    []string{"website"},
+				replication: map[string]string{"locked": "replica"},
+				logging:     map[string]string{"logged": "logs"},
+				policies: map[string]string{
+					"locked": `{"Statement": [
+						{"Effect": "Allow", "Principal": {"AWS": "arn:aws:iam::210987654321:root"}}
+					]}`,
+					"logged": `{"Statement": {
+						"Effect": "Allow",
+						"Principal": "*",
+						"Condition": {"StringEquals": {"aws:PrincipalOrgID": "o-1234567890"}}
+					}}`,
+					"website": `{"Statement": {"Effect": "Allow", "Principal": "*"}}`,
+				},
+				grants: map[string][]types.Grant{
+					"logged": {{Grantee: &types.Grantee{ID: aws.String("external")}, Permission: types.PermissionRead}},
+					"website": {{
+						Grantee:    &types.Grantee{URI: aws.String("http://acs.amazonaws.com/groups/global/AllUsers")},
+						Permission: types.PermissionRead,
+					}},
+				},
+			}))
+
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestS3BucketConfigQuery_Validate(t *testing.T) {
+	require.NoError(t, s3BucketConfigQuery{}.validate())
+	require.EqualError(t, s3BucketConfigQuery{versioning: "On"}.validate(), `unknown versioning status "On"`)
+	require.EqualError(
+		t, s3BucketConfigQuery{encryption: "kms"}.validate(), `unknown encryption algorithm "kms"`,
+	)
+	require.EqualError(
+		t, s3BucketConfigQuery{publicAccessBlock: "open"}.validate(), `unknown public access block state "open"`,
+	)
 }
 
 var _ s3Lister = &buckets{}
 
+// buckets returns a page per bucket, to check pagination is followed.
 type buckets struct {
-	buckets        []types.Bucket
-	bucketLocation map[string]types.BucketLocationConstraint
+	buckets           []types.Bucket
+	bucketTags        map[string][]types.Tag
+	publicAccessBlock map[string]*types.PublicAccessBlockConfiguration
+	encryption        map[string]*types.ServerSideEncryptionByDefault
+	versioning        map[string]types.BucketVersioningStatus
+	ownership         map[string]types.ObjectOwnership
+	websites          []string
+	replication       map[string]string
+	logging           map[string]string
+	policies          map[string]string
+	grants            map[string][]types.Grant
 }
 
 func (b *buckets) ListBuckets(
-	ctx context.Context, _ *s3.ListBucketsInput, _ ...func(*s3.Options),
+	ctx context.Context, params *s3.ListBucketsInput, _ ...func(*s3.Options),
 ) (*s3.ListBucketsOutput, error) {
 	if ctx == nil {
 		return nil, errors.New("missing context")
 	}
 
-	return &s3.ListBucketsOutput{
-		Buckets: b.buckets,
-	}, nil
+	var matching []types.Bucket
+	for _, bucket := range b.buckets {
+		if params.Prefix != nil && !strings.HasPrefix(aws.ToString(bucket.Name), *params.Prefix) {
+			continue
+		}
+		if params.BucketRegion != nil && s3BucketRegion(bucket) != *params.BucketRegion {
+			continue
+		}
+		matching = append(matching, bucket)
+	}
+
+	start := 0
+	if params.ContinuationToken != nil {
+		var err error
+		if start, err = strconv.Atoi(*params.ContinuationToken); err != nil {
+			return nil, err
+		}
+	}
+	if start >= len(matching) {
+		return &s3.ListBucketsOutput{}, nil
+	}
+
+	// Without MaxBuckets the buckets aren't paginated, which large accounts can't do, so only the first is returned
+	output := &s3.ListBucketsOutput{Buckets: matching[start : start+1]}
+	if params.MaxBuckets != nil && start+1 < len(matching) {
+		output.ContinuationToken = aws.String(strconv.Itoa(start + 1))
+	}
+	return output, nil
+}
+
+func (b *buckets) GetBucketTagging(
+	ctx context.Context, params *s3.GetBucketTaggingInput, optFns ...func(*s3.Options),
+) (*s3.GetBucketTaggingOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+
+	if tags, ok := b.bucketTags[aws.ToString(params.Bucket)]; ok {
+		return &s3.GetBucketTaggingOutput{TagSet: tags}, nil
+	}
+	return nil, &smithy.GenericAPIError{Code: "NoSuchTagSet"}
+}
+
+func (b *buckets) GetPublicAccessBlock(
+	ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options),
+) (*s3.GetPublicAccessBlockOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+
+	if config, ok := b.publicAccessBlock[aws.ToString(params.Bucket)]; ok {
+		return &s3.GetPublicAccessBlockOutput{PublicAccessBlockConfiguration: config}, nil
+	}
+	return nil, &smithy.GenericAPIError{Code: "NoSuchPublicAccessBlockConfiguration"}
 }
 
-func (b *buckets) GetBucketLocation(
-	ctx context.Context, params *s3.GetBucketLocationInput, _ ...func(*s3.Options),
-) (*s3.GetBucketLocationOutput, error) {
+func (b *buckets) GetBucketEncryption(
+	ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options),
+) (*s3.GetBucketEncryptionOutput, error) {
 	if ctx == nil {
 		return nil, errors.New("missing context")
 	}
-	if loc, ok := b.bucketLocation[aws.ToString(params.Bucket)]; ok {
-		return &s3.GetBucketLocationOutput{LocationConstraint: loc}, nil
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("invalid input")
+	if encryption, ok := b.encryption[aws.ToString(params.Bucket)]; ok {
+		return &s3.GetBucketEncryptionOutput{
+			ServerSideEncryptionConfiguration: &types.ServerSideEncryptionConfiguration{
+				Rules: []types.ServerSideEncryptionRule{{ApplyServerSideEncryptionByDefault: encryption}},
+			},
+		}, nil
+	}
+	return nil, &smithy.GenericAPIError{Code: "ServerSideEncryptionConfigurationNotFoundError"}
+}
+
+func (b *buckets) GetBucketVersioning(
+	ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options),
+) (*s3.GetBucketVersioningOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+
+	return &s3.GetBucketVersioningOutput{Status: b.versioning[aws.ToString(params.Bucket)]}, nil
+}
+
+func (b *buckets) GetBucketOwnershipControls(
+	ctx context.Context, params *s3.GetBucketOwnershipControlsInput, optFns ...func(*s3.Options),
+) (*s3.GetBucketOwnershipControlsOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+
+	if ownership, ok := b.ownership[aws.ToString(params.Bucket)]; ok {
+		return &s3.GetBucketOwnershipControlsOutput{
+			OwnershipControls: &types.OwnershipControls{
+				Rules: []types.OwnershipControlsRule{{ObjectOwnership: ownership}},
+			},
+		}, nil
+	}
+	return nil, &smithy.GenericAPIError{Code: "OwnershipControlsNotFoundError"}
+}
+
+func (b *buckets) GetBucketWebsite(
+	ctx context.Context, params *s3.GetBucketWebsiteInput, optFns ...func(*s3.Options),
+) (*s3.GetBucketWebsiteOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(b.websites, aws.ToString(params.Bucket)) {
+		return &s3.GetBucketWebsiteOutput{}, nil
+	}
+	return nil, &smithy.GenericAPIError{Code: "NoSuchWebsiteConfiguration"}
+}
+
+func (b *buckets) GetBucketReplication(
+	ctx context.Context, params *s3.GetBucketReplicationInput, optFns ...func(*s3.Options),
+) (*s3.GetBucketReplicationOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+
+	if destination, ok := b.replication[aws.ToString(params.Bucket)]; ok {
+		return &s3.GetBucketReplicationOutput{
+			ReplicationConfiguration: &types.ReplicationConfiguration{
+				Rules: []types.ReplicationRule{{Destination: &types.Destination{Bucket: aws.String(destination)}}},
+			},
+		}, nil
+	}
+	return nil, &smithy.GenericAPIError{Code: "ReplicationConfigurationNotFoundError"}
+}
+
+func (b *buckets) GetBucketLogging(
+	ctx context.Context, params *s3.GetBucketLoggingInput, optFns ...func(*s3.Options),
+) (*s3.GetBucketLoggingOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+
+	if target, ok := b.logging[aws.ToString(params.Bucket)]; ok {
+		return &s3.GetBucketLoggingOutput{LoggingEnabled: &types.LoggingEnabled{TargetBucket: aws.String(target)}}, nil
+	}
+	return &s3.GetBucketLoggingOutput{}, nil
+}
+
+func (b *buckets) GetBucketPolicy(
+	ctx context.Context, params *s3.GetBucketPolicyInput, optFns ...func(*s3.Options),
+) (*s3.GetBucketPolicyOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+
+	if policy, ok := b.policies[aws.ToString(params.Bucket)]; ok {
+		return &s3.GetBucketPolicyOutput{Policy: aws.String(policy)}, nil
+	}
+	return nil, &smithy.GenericAPIError{Code: "NoSuchBucketPolicy"}
+}
+
+func (b *buckets) GetBucketAcl(
+	ctx context.Context, params *s3.GetBucketAclInput, optFns ...func(*s3.Options),
+) (*s3.GetBucketAclOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if err := b.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+
+	owner := &types.Owner{ID: aws.String("owner")}
+	return &s3.GetBucketAclOutput{
+		Owner: owner,
+		Grants: append(
+			[]types.Grant{{Grantee: &types.Grantee{ID: owner.ID}, Permission: types.PermissionFullControl}},
+			b.grants[aws.ToString(params.Bucket)]...,
+		),
+	}, nil
+}
+
+// checkRegion fails requests that aren't sent to the region the bucket is in, as S3 would.
+func (b *buckets) checkRegion(name string, optFns []func(*s3.Options)) error {
+	var options s3.Options
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	for _, bucket := range b.buckets {
+		if aws.ToString(bucket.Name) == name && options.Region != s3BucketRegion(bucket) {
+			return fmt.Errorf("unexpected region %q", options.Region)
+		}
+	}
+	return nil
 }
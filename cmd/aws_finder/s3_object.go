@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/spf13/cobra"
+	"github.com/wjam/aws_finder/internal/finder"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func s3ObjectCmd() *cobra.Command {
+	query := s3ObjectQuery{maxKeys: 10000} //nolint:mnd // enough for most buckets without listing huge ones
+	cmd := &cobra.Command{
+		Use:   "s3_object <bucketNeedle> <keyNeedle>",
+		Short: "Find an S3 object by key in the buckets matching a name",
+		Args:  cobra.ExactArgs(2), //nolint:mnd // bucket and key
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if query.maxKeys < 1 {
+				return errors.New("max-keys must be at least 1")
+			}
+			query.bucketNeedle = args[0]
+			query.keyNeedle = args[1]
+			query.tags = tagFiltersFromContext(cmd.Context())
+			return finder.SearchPerProfile(
+				cmd.Context(),
+				func(ctx context.Context, conf aws.Config) error {
+					return findS3Object(ctx, query, s3.NewFromConfig(conf))
+				})
+		},
+	}
+	cmd.Flags().StringVar(&query.prefix, "prefix", "", "Only list keys starting with this prefix")
+	cmd.Flags().Int32Var(&query.maxKeys, "max-keys", query.maxKeys, "Stop listing a bucket after this many keys")
+	return cmd
+}
+
+// s3ObjectsPerPage is the most keys ListObjectsV2 returns at once.
+const s3ObjectsPerPage = 1000
+
+type s3ObjectQuery struct {
+	bucketNeedle string
+	keyNeedle    string
+	prefix       string
+	maxKeys      int32
+	tags         []tagFilter
+}
+
+func findS3Object(ctx context.Context, query s3ObjectQuery, client s3ObjectLister) error {
+	for bucket, err := range s3Buckets(ctx, client, &s3.ListBucketsInput{}) {
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(aws.ToString(bucket.Name), query.bucketNeedle) {
+			continue
+		}
+
+		matched, err := s3BucketMatchesTags(ctx, query.tags, client, bucket)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		if err := findObject(ctx, query, client, bucket); err != nil {
+			return fmt.Errorf("failed to list objects in bucket %q: %w", aws.ToString(bucket.Name), err)
+		}
+	}
+
+	return nil
+}
+
+func findObject(ctx context.Context, query s3ObjectQuery, client s3.ListObjectsV2APIClient, bucket types.Bucket) error {
+	input := &s3.ListObjectsV2Input{Bucket: bucket.Name, MaxKeys: aws.Int32(min(query.maxKeys, s3ObjectsPerPage))}
+	if query.prefix != "" {
+		input.Prefix = aws.String(query.prefix)
+	}
+	pages := s3.NewListObjectsV2Paginator(client, input)
+
+	var listed int32
+	for object, err := range paginatorToSeq(ctx, pages, objectsToObject, s3BucketRegionOption(bucket)) {
+		if err != nil {
+			return err
+		}
+		if listed >= query.maxKeys {
+			log.Logger(ctx).WarnContext(
+				ctx,
+				"stopped listing bucket after reaching the maximum number of keys",
+				slog.String("bucket", aws.ToString(bucket.Name)),
+				slog.Int("max-keys", int(query.maxKeys)),
+			)
+			return nil
+		}
+		listed++
+
+		if !strings.Contains(aws.ToString(object.Key), query.keyNeedle) {
+			continue
+		}
+
+		log.Logger(ctx).InfoContext(
+			ctx,
+			fmt.Sprintf("%s/%s", aws.ToString(bucket.Name), aws.ToString(object.Key)),
+			slog.Int64("size", aws.ToInt64(object.Size)),
+			slog.Time("last-modified", aws.ToTime(object.LastModified)),
+		)
+	}
+
+	return nil
+}
+
+func objectsToObject(r *s3.ListObjectsV2Output) iter.Seq[types.Object] {
+	return slices.Values(r.Contents)
+}
+
+type s3ObjectLister interface {
+	s3TagLister
+	s3.ListObjectsV2APIClient
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func TestFindS3Object(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    s3ObjectQuery
+		expected string
+	}{
+		{
+			"needles",
+			s3ObjectQuery{bucketNeedle: "data", keyNeedle: "report", maxKeys: 100},
+			"level=INFO msg=data-eu/2024/report.csv size=10 last-modified=2024-01-02T03:04:05.000Z\n" +
+				"level=INFO msg=data-us/reports/report.json size=30 last-modified=2024-01-02T03:04:05.000Z\n",
+		},
+		{
+			"prefix",
+			s3ObjectQuery{bucketNeedle: "data", keyNeedle: "report", prefix: "reports/", maxKeys: 100},
+			"level=INFO msg=data-us/reports/report.json size=30 last-modified=2024-01-02T03:04:05.000Z\n",
+		},
+		{
+			"max-keys",
+			s3ObjectQuery{bucketNeedle: "data-eu", maxKeys: 1},
+			"level=INFO msg=data-eu/2024/report.csv size=10 last-modified=2024-01-02T03:04:05.000Z\n" +
+				"level=WARN msg=\"stopped listing bucket after reaching the maximum number of keys\" " +
+				"bucket=data-eu max-keys=1\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			lastModified := aws.Time(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+			require.NoError(t, findS3Object(ctx, test.query, &objects{
+				buckets: buckets{
+					buckets: []types.Bucket{
+						{Name: aws.String("data-eu"), BucketRegion: aws.String("eu-west-1")},
+						{Name: aws.String("logs")},
+						{Name: aws.String("data-us")},
+					},
+				},
+				objects: map[string][]types.Object{
+					"data-eu": {
+						{Key: aws.String("2024/report.csv"), Size: aws.Int64(10), LastModified: lastModified},
+						{Key: aws.String("2024/summary.csv"), Size: aws.Int64(20), LastModified: lastModified},
+					},
+					"logs": {
+						{Key: aws.String("report.log"), Size: aws.Int64(5), LastModified: lastModified},
+					},
+					"data-us": {
+						{Key: aws.String("reports/report.json"), Size: aws.Int64(30), LastModified: lastModified},
+					},
+				},
+			}))
+
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+var _ s3ObjectLister = &objects{}
+
+// objects returns a page per object, to check pagination is followed.
+type objects struct {
+	buckets
+	objects map[string][]types.Object
+}
+
+func (o *objects) ListObjectsV2(
+	ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options),
+) (*s3.ListObjectsV2Output, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if err := o.checkRegion(aws.ToString(params.Bucket), optFns); err != nil {
+		return nil, err
+	}
+	if maxKeys := aws.ToInt32(params.MaxKeys); maxKeys < 1 || maxKeys > 1000 {
+		return nil, fmt.Errorf("invalid max keys %d", maxKeys)
+	}
+
+	var matching []types.Object
+	for _, object := range o.objects[aws.ToString(params.Bucket)] {
+		if strings.HasPrefix(aws.ToString(object.Key), aws.ToString(params.Prefix)) {
+			matching = append(matching, object)
+		}
+	}
+
+	start := 0
+	if params.ContinuationToken != nil {
+		var err error
+		if start, err = strconv.Atoi(*params.ContinuationToken); err != nil {
+			return nil, err
+		}
+	}
+	if start >= len(matching) {
+		return &s3.ListObjectsV2Output{}, nil
+	}
+
+	output := &s3.ListObjectsV2Output{Contents: matching[start : start+1]}
+	if start+1 < len(matching) {
+		output.IsTruncated = aws.Bool(true)
+		output.NextContinuationToken = aws.String(strconv.Itoa(start + 1))
+	}
+	return output, nil
+}
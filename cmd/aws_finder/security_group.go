@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+	"slices"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/cobra"
+	"github.com/wjam/aws_finder/internal/finder"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func securityGroupCmd() *cobra.Command {
+	var query securityGroupQuery
+	cmd := &cobra.Command{
+		Use:   "security_group [needle]",
+		Short: "Find a security group by ID, name, description or VPC, or by the rules it has",
+		Long: `Find a security group by ID, name, description or VPC, or by the rules it has.
+
+Rules are matched by what they allow rather than how they're written, so --cidr 10.1.2.3 finds a rule for 10.0.0.0/8
+and --port 22 finds a rule for ports 0-1024 or all traffic. Each matching rule is shown when searching by rule.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := query.rules.validate(); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				query.needle = args[0]
+			}
+			query.tags = tagFiltersFromContext(cmd.Context())
+			return finder.SearchPerRegion(
+				cmd.Context(),
+				func(ctx context.Context, conf aws.Config) error {
+					return findSecurityGroups(ctx, query, ec2.NewFromConfig(conf))
+				})
+		},
+	}
+	addSecurityGroupRuleFlags(cmd.Flags(), &query.rules)
+	return cmd
+}
+
+type securityGroupQuery struct {
+	needle string
+	rules  securityGroupRuleQuery
+	tags   []tagFilter
+}
+
+func findSecurityGroups(ctx context.Context, query securityGroupQuery, client securityGroupLister) error {
+	var rules map[string][]types.SecurityGroupRule
+	if query.rules.active() {
+		var err error
+		rules, err = matchingSecurityGroupRules(ctx, query.rules, client)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The needle isn't pushed down, as an ID could also be in the name or description of another group
+	pages := ec2.NewDescribeSecurityGroupsPaginator(client, &ec2.DescribeSecurityGroupsInput{
+		Filters: ec2TagFilters(query.tags),
+	})
+
+	seq := paginatorToSeq(ctx, pages, securityGroupsToSecurityGroup)
+	seq = filter2(func(group types.SecurityGroup, err error) bool {
+		return err != nil ||
+			check(query.needle, group.GroupId, group.GroupName, group.Description, group.VpcId) ||
+			ec2TagNeedleMatches(query.needle, group.Tags)
+	}, seq)
+
+	for group, err := range seq {
+		if err != nil {
+			return err
+		}
+
+		attrs := []any{
+			slog.String("name", aws.ToString(group.GroupName)),
+			slog.String("vpc", aws.ToString(group.VpcId)),
+		}
+		if !query.rules.active() {
+			log.Logger(ctx).InfoContext(ctx, aws.ToString(group.GroupId), attrs...)
+			continue
+		}
+		for _, rule := range rules[aws.ToString(group.GroupId)] {
+			log.Logger(ctx).InfoContext(
+				ctx, aws.ToString(group.GroupId), append(slices.Clone(attrs), securityGroupRuleAttrs(rule)...)...,
+			)
+		}
+	}
+
+	return nil
+}
+
+func securityGroupsToSecurityGroup(r *ec2.DescribeSecurityGroupsOutput) iter.Seq[types.SecurityGroup] {
+	return slices.Values(r.SecurityGroups)
+}
+
+type securityGroupLister interface {
+	ec2.DescribeSecurityGroupsAPIClient
+	ec2.DescribeSecurityGroupRulesAPIClient
+}
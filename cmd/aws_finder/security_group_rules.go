@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/spf13/pflag"
+)
+
+const (
+	ingressDirection = "ingress"
+	egressDirection  = "egress"
+	// allProtocols is the protocol of rules allowing all traffic.
+	allProtocols = "-1"
+)
+
+// securityGroupRuleQuery finds the rules allowing traffic, rather than matching how the rule is written.
+type securityGroupRuleQuery struct {
+	direction       string
+	protocol        string
+	port            string
+	cidr            string
+	referencedGroup string
+	prefixList      string
+}
+
+func addSecurityGroupRuleFlags(flags *pflag.FlagSet, query *securityGroupRuleQuery) {
+	flags.StringVar(
+		&query.direction,
+		"direction",
+		"",
+		fmt.Sprintf("Only find rules in this direction (%s, %s)", ingressDirection, egressDirection),
+	)
+	flags.StringVar(
+		&query.protocol, "protocol", "", "Only find rules allowing this protocol, such as tcp, udp or icmp",
+	)
+	flags.StringVar(
+		&query.port, "port", "", "Only find rules allowing this TCP or UDP port or range, such as 22 or 80-443",
+	)
+	flags.StringVar(&query.cidr, "cidr", "", "Only find rules allowing this IP address or CIDR range")
+	flags.StringVar(&query.referencedGroup, "referenced-group", "", "Only find rules allowing this security group ID")
+	flags.StringVar(&query.prefixList, "prefix-list", "", "Only find rules allowing this prefix list ID")
+}
+
+func (q securityGroupRuleQuery) active() bool {
+	return q != securityGroupRuleQuery{}
+}
+
+func (q securityGroupRuleQuery) validate() error {
+	if q.direction != "" && q.direction != ingressDirection && q.direction != egressDirection {
+		return fmt.Errorf("unknown rule direction %q", q.direction)
+	}
+	if q.port != "" {
+		if _, _, err := parsePortRange(q.port); err != nil {
+			return err
+		}
+	}
+	if q.cidr != "" {
+		if _, err := parseCidrOrAddress(q.cidr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q securityGroupRuleQuery) matches(rule types.SecurityGroupRule) bool {
+	if q.direction != "" && q.direction != securityGroupRuleDirection(rule) {
+		return false
+	}
+	if q.protocol != "" && !securityGroupRuleAllowsProtocol(rule, q.protocol) {
+		return false
+	}
+	if q.port != "" {
+		from, to, _ := parsePortRange(q.port)
+		if !securityGroupRuleAllowsPorts(rule, from, to) {
+			return false
+		}
+	}
+	if q.cidr != "" {
+		prefix, _ := parseCidrOrAddress(q.cidr)
+		if !securityGroupRuleAllowsCidr(rule, prefix) {
+			return false
+		}
+	}
+	if q.referencedGroup != "" &&
+		(rule.ReferencedGroupInfo == nil || aws.ToString(rule.ReferencedGroupInfo.GroupId) != q.referencedGroup) {
+		return false
+	}
+	return q.prefixList == "" || aws.ToString(rule.PrefixListId) == q.prefixList
+}
+
+// matchingSecurityGroupRules returns the rules matching the query, keyed by the ID of their security group. Every
+// rule in the region is listed at once, rather than per security group.
+func matchingSecurityGroupRules(
+	ctx context.Context, query securityGroupRuleQuery, client ec2.DescribeSecurityGroupRulesAPIClient,
+) (map[string][]types.SecurityGroupRule, error) {
+	pages := ec2.NewDescribeSecurityGroupRulesPaginator(client, &ec2.DescribeSecurityGroupRulesInput{})
+
+	rules := map[string][]types.SecurityGroupRule{}
+	for rule, err := range paginatorToSeq(ctx, pages, securityGroupRulesToSecurityGroupRule) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list security group rules: %w", err)
+		}
+		if query.matches(rule) {
+			rules[aws.ToString(rule.GroupId)] = append(rules[aws.ToString(rule.GroupId)], rule)
+		}
+	}
+	return rules, nil
+}
+
+func securityGroupRulesToSecurityGroupRule(r *ec2.DescribeSecurityGroupRulesOutput) iter.Seq[types.SecurityGroupRule] {
+	return slices.Values(r.SecurityGroupRules)
+}
+
+func securityGroupRuleAttrs(rule types.SecurityGroupRule) []any {
+	return []any{
+		slog.String("rule", aws.ToString(rule.SecurityGroupRuleId)),
+		slog.String("direction", securityGroupRuleDirection(rule)),
+		slog.String("protocol", securityGroupRuleProtocol(rule)),
+		slog.String("ports", securityGroupRulePorts(rule)),
+		slog.String("peer", securityGroupRulePeer(rule)),
+	}
+}
+
+func securityGroupRuleDirection(rule types.SecurityGroupRule) string {
+	if aws.ToBool(rule.IsEgress) {
+		return egressDirection
+	}
+	return ingressDirection
+}
+
+// securityGroupRuleProtocols maps the protocol numbers rules may use to the names they otherwise use.
+func securityGroupRuleProtocols() map[string]string {
+	return map[string]string{"1": "icmp", "6": "tcp", "17": "udp", "58": "icmpv6"}
+}
+
+func securityGroupRuleProtocol(rule types.SecurityGroupRule) string {
+	protocol := aws.ToString(rule.IpProtocol)
+	if name, ok := securityGroupRuleProtocols()[protocol]; ok {
+		return name
+	}
+	return protocol
+}
+
+func securityGroupRuleAllowsProtocol(rule types.SecurityGroupRule, protocol string) bool {
+	ruleProtocol := securityGroupRuleProtocol(rule)
+	if name, ok := securityGroupRuleProtocols()[protocol]; ok {
+		protocol = name
+	}
+	return ruleProtocol == allProtocols || strings.EqualFold(ruleProtocol, protocol)
+}
+
+// securityGroupRuleAllowsPorts checks the rule allows every port in the range. Only TCP and UDP rules have ports, so
+// other rules only allow ports when they allow all traffic.
+func securityGroupRuleAllowsPorts(rule types.SecurityGroupRule, from, to int32) bool {
+	switch securityGroupRuleProtocol(rule) {
+	case allProtocols:
+		return true
+	case "tcp", "udp":
+		return aws.ToInt32(rule.FromPort) <= from && to <= aws.ToInt32(rule.ToPort)
+	default:
+		return false
+	}
+}
+
+// securityGroupRuleAllowsCidr checks the rule allows every address in the prefix.
+func securityGroupRuleAllowsCidr(rule types.SecurityGroupRule, prefix netip.Prefix) bool {
+	for _, cidr := range []*string{rule.CidrIpv4, rule.CidrIpv6} {
+		if cidr == nil {
+			continue
+		}
+		ruleCidr, err := netip.ParsePrefix(*cidr)
+		if err != nil {
+			continue
+		}
+		if ruleCidr.Bits() <= prefix.Bits() && ruleCidr.Contains(prefix.Addr()) {
+			return true
+		}
+	}
+	return false
+}
+
+// securityGroupRulePorts returns the port range of the rule, or the type of ICMP rules where a code of -1 allows every
+// code.
+func securityGroupRulePorts(rule types.SecurityGroupRule) string {
+	from, to := aws.ToInt32(rule.FromPort), aws.ToInt32(rule.ToPort)
+	switch {
+	case securityGroupRuleProtocol(rule) == allProtocols || from == -1:
+		return "all"
+	case from == to || to == -1:
+		return strconv.Itoa(int(from))
+	default:
+		return fmt.Sprintf("%d-%d", from, to)
+	}
+}
+
+// securityGroupRulePeer returns the source of an ingress rule or the destination of an egress rule.
+func securityGroupRulePeer(rule types.SecurityGroupRule) string {
+	switch {
+	case rule.CidrIpv4 != nil:
+		return aws.ToString(rule.CidrIpv4)
+	case rule.CidrIpv6 != nil:
+		return aws.ToString(rule.CidrIpv6)
+	case rule.PrefixListId != nil:
+		return aws.ToString(rule.PrefixListId)
+	case rule.ReferencedGroupInfo != nil:
+		return aws.ToString(rule.ReferencedGroupInfo.GroupId)
+	default:
+		return ""
+	}
+}
+
+// parsePortRange parses either a single port, such as 22, or an inclusive range, such as 80-443.
+func parsePortRange(s string) (int32, int32, error) {
+	fromValue, toValue, isRange := strings.Cut(s, "-")
+	if !isRange {
+		toValue = fromValue
+	}
+	from, fromErr := strconv.ParseUint(fromValue, 10, 16)
+	to, toErr := strconv.ParseUint(toValue, 10, 16)
+	if fromErr != nil || toErr != nil {
+		return 0, 0, fmt.Errorf("%q is neither a port nor a range of ports, such as 22 or 80-443", s)
+	}
+	if to < from {
+		return 0, 0, errors.New("the end of a port range must not be before its start")
+	}
+	return int32(from), int32(to), nil //nolint:gosec // parsed as 16 bit numbers so can't overflow
+}
+
+// parseCidrOrAddress parses a CIDR range, treating a single IP address as a range of just that address.
+func parseCidrOrAddress(s string) (netip.Prefix, error) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%q is neither an IP address nor a CIDR range", s)
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/netip"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func TestFindSecurityGroups(t *testing.T) {
+	groups := []types.SecurityGroup{
+		{
+			GroupId:     aws.String("sg-web"),
+			GroupName:   aws.String("web"),
+			Description: aws.String("Public web servers"),
+			VpcId:       aws.String("vpc-1"),
+		},
+		{
+			GroupId:     aws.String("sg-db"),
+			GroupName:   aws.String("database"),
+			Description: aws.String("Postgres, reachable from sg-web"),
+			VpcId:       aws.String("vpc-2"),
+			Tags:        []types.Tag{{Key: aws.String("team"), Value: aws.String("data")}},
+		},
+	}
+	rules := []types.SecurityGroupRule{
+		{
+			SecurityGroupRuleId: aws.String("sgr-ssh"),
+			GroupId:             aws.String("sg-web"),
+			IpProtocol:          aws.String("tcp"),
+			FromPort:            aws.Int32(22),
+			ToPort:              aws.Int32(22),
+			CidrIpv4:            aws.String("0.0.0.0/0"),
+		},
+		{
+			SecurityGroupRuleId: aws.String("sgr-high"),
+			GroupId:             aws.String("sg-web"),
+			IpProtocol:          aws.String("6"),
+			FromPort:            aws.Int32(1024),
+			ToPort:              aws.Int32(65535),
+			CidrIpv4:            aws.String("10.0.0.0/8"),
+		},
+		{
+			SecurityGroupRuleId: aws.String("sgr-all-out"),
+			GroupId:             aws.String("sg-web"),
+			IsEgress:            aws.Bool(true),
+			IpProtocol:          aws.String("-1"),
+			FromPort:            aws.Int32(-1),
+			ToPort:              aws.Int32(-1),
+			CidrIpv4:            aws.String("0.0.0.0/0"),
+		},
+		{
+			SecurityGroupRuleId: aws.String("sgr-postgres"),
+			GroupId:             aws.String("sg-db"),
+			IpProtocol:          aws.String("tcp"),
+			FromPort:            aws.Int32(5432),
+			ToPort:              aws.Int32(5432),
+			ReferencedGroupInfo: &types.ReferencedSecurityGroup{GroupId: aws.String("sg-web")},
+		},
+		{
+			SecurityGroupRuleId: aws.String("sgr-ping"),
+			GroupId:             aws.String("sg-db"),
+			IpProtocol:          aws.String("icmp"),
+			FromPort:            aws.Int32(8),
+			ToPort:              aws.Int32(-1),
+			PrefixListId:        aws.String("pl-office"),
+		},
+	}
+
+	var tests = []struct {
+		name     string
+		query    securityGroupQuery
+		filters  []types.Filter
+		expected string
+	}{
+		{
+			"description",
+			securityGroupQuery{needle: "Postgres"},
+			nil,
+			"level=INFO msg=sg-db name=database vpc=vpc-2\n",
+		},
+		{
+			"vpc",
+			securityGroupQuery{needle: "vpc-2"},
+			nil,
+			"level=INFO msg=sg-db name=database vpc=vpc-2\n",
+		},
+		{
+			"id in description",
+			securityGroupQuery{needle: "sg-web"},
+			nil,
+			"level=INFO msg=sg-web name=web vpc=vpc-1\nlevel=INFO msg=sg-db name=database vpc=vpc-2\n",
+		},
+		{
+			"tag",
+			securityGroupQuery{needle: "team=data"},
+			nil,
+			"level=INFO msg=sg-db name=database vpc=vpc-2\n",
+		},
+		{
+			"open ssh",
+			securityGroupQuery{rules: securityGroupRuleQuery{direction: "ingress", cidr: "0.0.0.0/0", port: "22"}},
+			nil,
+			"level=INFO msg=sg-web name=web vpc=vpc-1 rule=sgr-ssh direction=ingress protocol=tcp ports=22 " +
+				"peer=0.0.0.0/0\n",
+		},
+		{
+			"address within range",
+			securityGroupQuery{rules: securityGroupRuleQuery{
+				direction: "ingress", cidr: "10.1.2.3", port: "8080-8443", protocol: "tcp",
+			}},
+			nil,
+			"level=INFO msg=sg-web name=web vpc=vpc-1 rule=sgr-high direction=ingress protocol=tcp ports=1024-65535 " +
+				"peer=10.0.0.0/8\n",
+		},
+		{
+			"all traffic allows any port",
+			securityGroupQuery{rules: securityGroupRuleQuery{direction: "egress", port: "443", protocol: "udp"}},
+			nil,
+			"level=INFO msg=sg-web name=web vpc=vpc-1 rule=sgr-all-out direction=egress protocol=-1 ports=all " +
+				"peer=0.0.0.0/0\n",
+		},
+		{
+			"referenced group",
+			securityGroupQuery{rules: securityGroupRuleQuery{referencedGroup: "sg-web"}},
+			nil,
+			"level=INFO msg=sg-db name=database vpc=vpc-2 rule=sgr-postgres direction=ingress protocol=tcp " +
+				"ports=5432 peer=sg-web\n",
+		},
+		{
+			"prefix list",
+			securityGroupQuery{rules: securityGroupRuleQuery{prefixList: "pl-office"}},
+			nil,
+			"level=INFO msg=sg-db name=database vpc=vpc-2 rule=sgr-ping direction=ingress protocol=icmp ports=8 " +
+				"peer=pl-office\n",
+		},
+		{
+			"icmp has no ports",
+			securityGroupQuery{rules: securityGroupRuleQuery{prefixList: "pl-office", port: "8"}},
+			nil,
+			"",
+		},
+		{
+			"needle and rules",
+			securityGroupQuery{needle: "web", rules: securityGroupRuleQuery{cidr: "0.0.0.0/0"}},
+			nil,
+			"level=INFO msg=sg-web name=web vpc=vpc-1 rule=sgr-ssh direction=ingress protocol=tcp ports=22 " +
+				"peer=0.0.0.0/0\n" +
+				"level=INFO msg=sg-web name=web vpc=vpc-1 rule=sgr-all-out direction=egress protocol=-1 ports=all " +
+				"peer=0.0.0.0/0\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			err := findSecurityGroups(ctx, test.query, &securityGroups{
+				filters: test.filters,
+				groups:  groups,
+				rules:   rules,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestSecurityGroupRuleQuery_Validate(t *testing.T) {
+	require.NoError(t, securityGroupRuleQuery{direction: "egress", port: "80-443", cidr: "2001:db8::/32"}.validate())
+	require.EqualError(t, securityGroupRuleQuery{direction: "inbound"}.validate(), `unknown rule direction "inbound"`)
+	require.EqualError(
+		t,
+		securityGroupRuleQuery{port: "ssh"}.validate(),
+		`"ssh" is neither a port nor a range of ports, such as 22 or 80-443`,
+	)
+	require.EqualError(
+		t, securityGroupRuleQuery{port: "443-80"}.validate(), "the end of a port range must not be before its start",
+	)
+	require.EqualError(
+		t,
+		securityGroupRuleQuery{cidr: "10.0.0.0/33"}.validate(),
+		`"10.0.0.0/33" is neither an IP address nor a CIDR range`,
+	)
+}
+
+func TestParseCidrOrAddress(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected netip.Prefix
+	}{
+		{"10.1.2.3", netip.MustParsePrefix("10.1.2.3/32")},
+		{"10.1.2.3/16", netip.MustParsePrefix("10.1.0.0/16")},
+		{"2001:db8::1", netip.MustParsePrefix("2001:db8::1/128")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			actual, err := parseCidrOrAddress(test.value)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, actual)
+		})
+	}
+}
+
+var _ securityGroupLister = &securityGroups{}
+
+type securityGroups struct {
+	filters []types.Filter
+	groups  []types.SecurityGroup
+	rules   []types.SecurityGroupRule
+}
+
+func (s *securityGroups) DescribeSecurityGroups(
+	ctx context.Context, params *ec2.DescribeSecurityGroupsInput, _ ...func(*ec2.Options),
+) (*ec2.DescribeSecurityGroupsOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if !reflect.DeepEqual(params.Filters, s.filters) {
+		return nil, errors.New("unexpected filters")
+	}
+	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: s.groups}, nil
+}
+
+func (s *securityGroups) DescribeSecurityGroupRules(
+	ctx context.Context, _ *ec2.DescribeSecurityGroupRulesInput, _ ...func(*ec2.Options),
+) (*ec2.DescribeSecurityGroupRulesOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	return &ec2.DescribeSecurityGroupRulesOutput{SecurityGroupRules: s.rules}, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+// tagFilter restricts results to resources with the given tag. A filter without a value only requires the tag to be
+// present, while the value may contain `*` to match any sequence of characters and `?` to match any single character,
+// the same as EC2 filters.
+type tagFilter struct {
+	key     string
+	value   *string
+	pattern *regexp.Regexp
+}
+
+func parseTagFilter(s string) (tagFilter, error) {
+	key, value, hasValue := strings.Cut(s, "=")
+	if key == "" {
+		return tagFilter{}, errors.New("tag filter must have a key")
+	}
+	if !hasValue {
+		return tagFilter{key: key}, nil
+	}
+
+	var pattern strings.Builder
+	for _, r := range value {
+		switch r {
+		case '*':
+			pattern.WriteString(".*")
+		case '?':
+			pattern.WriteString(".")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return tagFilter{
+		key:     key,
+		value:   aws.String(value),
+		pattern: regexp.MustCompile("^" + pattern.String() + "$"),
+	}, nil
+}
+
+func (t tagFilter) String() string {
+	if t.value == nil {
+		return t.key
+	}
+	return t.key + "=" + *t.value
+}
+
+func (t tagFilter) hasWildcard() bool {
+	return t.value != nil && strings.ContainsAny(*t.value, "*?")
+}
+
+func (t tagFilter) matches(tags map[string]string) bool {
+	value, ok := tags[t.key]
+	if !ok {
+		return false
+	}
+	return t.pattern == nil || t.pattern.MatchString(value)
+}
+
+// matchesTagFilters returns true if the tags satisfy every filter.
+func matchesTagFilters(filters []tagFilter, tags map[string]string) bool {
+	for _, filter := range filters {
+		if !filter.matches(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+// ec2TagFilters converts the filters into their EC2 equivalent, which natively support `*` and `?` in tag values.
+func ec2TagFilters(filters []tagFilter) []types.Filter {
+	var ret []types.Filter
+	for _, filter := range filters {
+		if filter.value == nil {
+			ret = append(ret, types.Filter{Name: aws.String("tag-key"), Values: []string{filter.key}})
+			continue
+		}
+		ret = append(ret, types.Filter{Name: aws.String("tag:" + filter.key), Values: []string{*filter.value}})
+	}
+	return ret
+}
+
+type tagFiltersKey struct{}
+
+func contextWithTagFilters(ctx context.Context, filters []tagFilter) context.Context {
+	return context.WithValue(ctx, tagFiltersKey{}, filters)
+}
+
+func tagFiltersFromContext(ctx context.Context) []tagFilter {
+	filters, _ := ctx.Value(tagFiltersKey{}).([]tagFilter)
+	return filters
+}
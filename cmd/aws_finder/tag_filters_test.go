@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTagFilter_Matches(t *testing.T) {
+	var tests = []struct {
+		filter   string
+		tags     map[string]string
+		expected bool
+	}{
+		{"team", map[string]string{"team": ""}, true},
+		{"team", map[string]string{"owner": "team"}, false},
+		{"team=payments", map[string]string{"team": "payments"}, true},
+		{"team=payments", map[string]string{"team": "payments-api"}, false},
+		{"team=pay*", map[string]string{"team": "payments-api"}, true},
+		{"team=*-api", map[string]string{"team": "payments-api"}, true},
+		{"team=*-api", map[string]string{"team": "payments-api-v2"}, false},
+		{"name=a.b", map[string]string{"name": "axb"}, false},
+		{"env=prod-?", map[string]string{"env": "prod-1"}, true},
+		{"env=prod-?", map[string]string{"env": "prod-12"}, false},
+		{"env=prod-?", map[string]string{"env": "prod-"}, false},
+		{"team=", map[string]string{"team": ""}, true},
+		{"team=", map[string]string{"team": "payments"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filter, func(t *testing.T) {
+			filter, err := parseTagFilter(test.filter)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, filter.matches(test.tags))
+			assert.Equal(t, test.filter, filter.String())
+		})
+	}
+}
+
+func TestParseTagFilter_MissingKey(t *testing.T) {
+	_, err := parseTagFilter("=value")
+	require.EqualError(t, err, "tag filter must have a key")
+}
+
+func TestEc2TagFilters(t *testing.T) {
+	flag := &tagFiltersFlag{}
+	require.NoError(t, flag.Set("owner"))
+	require.NoError(t, flag.Set("env=prod*"))
+
+	assert.Equal(t, "owner,env=prod*", flag.String())
+	assert.Equal(t, []types.Filter{
+		{Name: aws.String("tag-key"), Values: []string{"owner"}},
+		{Name: aws.String("tag:env"), Values: []string{"prod*"}},
+	}, ec2TagFilters(flag.filters))
+}
@@ -2,8 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"iter"
+	"log/slog"
+	"maps"
 	"slices"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
@@ -14,48 +19,305 @@ import (
 )
 
 func tagCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "tag [key] <value> <value>",
+	var query tagQuery
+	cmd := &cobra.Command{
+		Use:   "tag [query]",
 		Short: "Find resources by tags",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Find resources by tags.
+
+The query is either a tag key followed by the values it may have, such as "env prod dev", or conditions joined by AND,
+each of which is a key that must be present or a key=value pair, optionally preceded by NOT. Values may contain * to
+match any sequence of characters and ? to match any single character, such as "env=prod AND team=pay* AND NOT owner".
+AND and NOT are only recognised in upper case, so keys such as "and" can still be queried.
+
+With --missing, resources without all the given tags are found instead, including resources that have never been
+tagged for the types that can be listed directly. The query is then optional and restricts which resources are
+checked.`,
+		Args: cobra.ArbitraryArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return finder.SearchPerRegion(
-				cmd.Context(),
-				func(ctx context.Context, conf aws.Config) error {
-					return findByTag(ctx, resourcegroupstaggingapi.NewFromConfig(conf), args[0], args[1:]...)
-				})
+			if len(args) == 0 && len(query.missing) == 0 {
+				return errors.New("requires a tag query, --missing or both")
+			}
+			if len(args) != 0 {
+				conditions, err := parseTagQuery(args)
+				if err != nil {
+					return err
+				}
+				query.conditions = conditions
+			}
+			for _, tag := range tagFiltersFromContext(cmd.Context()) {
+				query.conditions = append(query.conditions, tagCondition{filters: []tagFilter{tag}})
+			}
+			return searchByTag(cmd.Context(), query)
 		},
 	}
+	cmd.Flags().StringSliceVar(
+		&query.resourceTypes,
+		"resource-type",
+		nil,
+		"Only find resources of these types, such as ec2:instance or s3",
+	)
+	cmd.Flags().StringSliceVar(
+		&query.showTags, "show-tags", nil, "Only show these tag keys in the results, rather than every tag",
+	)
+	cmd.Flags().StringSliceVar(
+		&query.missing, "missing", nil, "Find resources that are missing any of these mandatory tags",
+	)
+	cmd.AddCommand(tagValuesCmd())
+	return cmd
+}
+
+type tagQuery struct {
+	conditions    []tagCondition
+	resourceTypes []string
+	showTags      []string
+	missing       []string
+	// nativeTypes are the resource types searched using their own service, so are ignored if the tagging API
+	// returns them.
+	nativeTypes []string
+}
+
+// searchByTag uses the tagging API in every region, along with the native searchers for the resource types it doesn't
+// fully cover.
+func searchByTag(ctx context.Context, query tagQuery) error {
+	searchers := nativeTagSearchersFor(query.resourceTypes, len(query.missing) != 0)
+	query.nativeTypes = slices.Sorted(maps.Keys(searchers))
+	if len(query.nativeTypes) != 0 {
+		log.Logger(ctx).InfoContext(
+			ctx,
+			"searching resource types using their own service",
+			slog.String("types", strings.Join(query.nativeTypes, ",")),
+		)
+	}
+
+	searchNatively := func(ctx context.Context, conf aws.Config, regional bool) error {
+		for _, resourceType := range query.nativeTypes {
+			searcher := searchers[resourceType]
+			if searcher.regional != regional {
+				continue
+			}
+			if err := findByTagNatively(ctx, query, searcher.search(ctx, conf)); err != nil {
+				return fmt.Errorf("failed to search %s: %w", resourceType, err)
+			}
+		}
+		return nil
+	}
+
+	regionalErr := finder.SearchPerRegion(
+		ctx,
+		func(ctx context.Context, conf aws.Config) error {
+			if err := findByTag(ctx, resourcegroupstaggingapi.NewFromConfig(conf), query); err != nil {
+				return err
+			}
+			return searchNatively(ctx, conf, true)
+		})
+	globalErr := finder.SearchPerProfile(
+		ctx,
+		func(ctx context.Context, conf aws.Config) error {
+			return searchNatively(ctx, conf, false)
+		})
+	return errors.Join(regionalErr, globalErr)
 }
 
 func findByTag(
 	ctx context.Context,
 	client resourcegroupstaggingapi.GetResourcesAPIClient,
-	key string,
-	values ...string,
+	query tagQuery,
 ) error {
-	// TODO need to identify what type of resources the resourcegroupstaggingapi doesn't support
+	var tagFilters []types.TagFilter
+	var clientSide []tagCondition
+	for _, condition := range query.conditions {
+		filter, exact := condition.tagFilter()
+		if filter != nil {
+			tagFilters = append(tagFilters, *filter)
+		}
+		if !exact {
+			clientSide = append(clientSide, condition)
+		}
+	}
 
 	pages := resourcegroupstaggingapi.NewGetResourcesPaginator(client, &resourcegroupstaggingapi.GetResourcesInput{
-		TagFilters: []types.TagFilter{
-			{
-				Key:    aws.String(key),
-				Values: values,
-			},
-		},
+		TagFilters:          tagFilters,
+		ResourceTypeFilters: query.resourceTypes,
 	})
 
-	for resource, err := range paginatorToSeq(ctx, pages, tagMappingListToResource) {
+	seq := paginatorToSeq(ctx, pages, tagMappingListToResource)
+	seq = filter2(func(resource types.ResourceTagMapping, err error) bool {
+		if err != nil {
+			return true
+		}
+		if slices.Contains(query.nativeTypes, arnResourceType(aws.ToString(resource.ResourceARN))) {
+			return false
+		}
+		return matchesTagConditions(clientSide, resourceTags(resource))
+	}, seq)
+
+	for resource, err := range seq {
 		if err != nil {
 			return err
 		}
 
-		log.Logger(ctx).InfoContext(ctx, aws.ToString(resource.ResourceARN))
+		reportTaggedResource(ctx, query, aws.ToString(resource.ResourceARN), resourceTags(resource))
 	}
 
 	return nil
 }
 
+// reportTaggedResource logs the resource, unless the query is for missing tags and the resource has them all.
+func reportTaggedResource(ctx context.Context, query tagQuery, arn string, tags map[string]string) {
+	attrs := []any{tagsAttr(tags, query.showTags)}
+	if len(query.missing) != 0 {
+		var missing []string
+		for _, key := range query.missing {
+			if _, ok := tags[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) == 0 {
+			return
+		}
+		attrs = append(attrs, slog.String("missing", strings.Join(missing, ",")))
+	}
+
+	log.Logger(ctx).InfoContext(ctx, arn, attrs...)
+}
+
+// tagsAttr groups the tags, or just those with the given keys, into a single attribute for logging.
+func tagsAttr(tags map[string]string, keys []string) slog.Attr {
+	if len(keys) == 0 {
+		keys = slices.Sorted(maps.Keys(tags))
+	}
+
+	var attrs []any
+	for _, key := range keys {
+		if value, ok := tags[key]; ok {
+			attrs = append(attrs, slog.String(key, value))
+		}
+	}
+	return slog.Group("tags", attrs...)
+}
+
+// tagCondition is a single term of a tag query, which holds if any of its filters match the tags of a resource or,
+// when negated, if none of them do. All the filters share the same key.
+type tagCondition struct {
+	filters []tagFilter
+	negate  bool
+}
+
+func matchesTagConditions(conditions []tagCondition, tags map[string]string) bool {
+	for _, condition := range conditions {
+		if !condition.matches(tags) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c tagCondition) matches(tags map[string]string) bool {
+	for _, filter := range c.filters {
+		if filter.matches(tags) {
+			return !c.negate
+		}
+	}
+	return c.negate
+}
+
+// tagFilter converts the condition into its tagging API equivalent, if there is one, and whether that is exact or the
+// condition still needs to be checked client-side.
+func (c tagCondition) tagFilter() (*types.TagFilter, bool) {
+	if c.negate {
+		return nil, false
+	}
+
+	filter := &types.TagFilter{Key: aws.String(c.filters[0].key)}
+	for _, f := range c.filters {
+		if f.value == nil {
+			return &types.TagFilter{Key: filter.Key}, true
+		}
+		// The tagging API doesn't support wildcards, so any value is fetched
+		if f.hasWildcard() {
+			return &types.TagFilter{Key: filter.Key}, false
+		}
+		filter.Values = append(filter.Values, *f.value)
+	}
+	return filter, true
+}
+
+// parseTagQuery parses either a key followed by its possible values, or conditions joined by AND.
+func parseTagQuery(args []string) ([]tagCondition, error) {
+	if isKeyValuesTagQuery(args) {
+		condition := tagCondition{}
+		for _, value := range args[1:] {
+			filter, err := parseTagFilter(args[0] + "=" + value)
+			if err != nil {
+				return nil, err
+			}
+			condition.filters = append(condition.filters, filter)
+		}
+		return []tagCondition{condition}, nil
+	}
+
+	var conditions []tagCondition
+	expectCondition := true
+	negate := false
+	for _, arg := range args {
+		switch {
+		case arg == tagQueryAnd:
+			if expectCondition {
+				return nil, fmt.Errorf("unexpected %s in tag query", tagQueryAnd)
+			}
+			expectCondition = true
+		case arg == tagQueryNot:
+			if !expectCondition || negate {
+				return nil, fmt.Errorf("unexpected %s in tag query", tagQueryNot)
+			}
+			negate = true
+		default:
+			if !expectCondition {
+				return nil, fmt.Errorf("expected %s before %q in tag query", tagQueryAnd, arg)
+			}
+			filter, err := parseTagFilter(arg)
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, tagCondition{filters: []tagFilter{filter}, negate: negate})
+			expectCondition = false
+			negate = false
+		}
+	}
+	if expectCondition {
+		return nil, errors.New("incomplete tag query")
+	}
+
+	return conditions, nil
+}
+
+const (
+	tagQueryAnd = "AND"
+	tagQueryNot = "NOT"
+)
+
+// isKeyValuesTagQuery returns true for the original form of query, a key followed by the values it may have.
+func isKeyValuesTagQuery(args []string) bool {
+	if len(args) < 2 { //nolint:mnd // a key and at least one value
+		return false
+	}
+	for _, arg := range args {
+		if strings.Contains(arg, "=") || arg == tagQueryAnd || arg == tagQueryNot {
+			return false
+		}
+	}
+	return true
+}
+
 func tagMappingListToResource(r *resourcegroupstaggingapi.GetResourcesOutput) iter.Seq[types.ResourceTagMapping] {
 	return slices.Values(r.ResourceTagMappingList)
 }
+
+func resourceTags(resource types.ResourceTagMapping) map[string]string {
+	tags := make(map[string]string, len(resource.Tags))
+	for _, tag := range resource.Tags {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/cloudfront"
+	cloudfronttypes "github.com/aws/aws-sdk-go-v2/service/cloudfront/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// taggedResource is a resource found using the tagging API of its own service, along with every tag it has.
+type taggedResource struct {
+	arn  string
+	tags map[string]string
+}
+
+type nativeTagSearcher struct {
+	// regional searchers run in every region, rather than once per profile for global services.
+	regional bool
+	search   func(context.Context, aws.Config) iter.Seq2[taggedResource, error]
+}
+
+// nativeTagSearchers returns, keyed by resource type, how to find resources that the Resource Groups Tagging API
+// doesn't cover.
+func nativeTagSearchers() map[string]nativeTagSearcher {
+	return map[string]nativeTagSearcher{
+		"cloudfront:distribution": {
+			search: func(ctx context.Context, conf aws.Config) iter.Seq2[taggedResource, error] {
+				return cloudfrontTaggedDistributions(ctx, cloudfront.NewFromConfig(conf))
+			},
+		},
+		"iam:role": {
+			search: func(ctx context.Context, conf aws.Config) iter.Seq2[taggedResource, error] {
+				return iamTaggedRoles(ctx, iam.NewFromConfig(conf))
+			},
+		},
+		"iam:user": {
+			search: func(ctx context.Context, conf aws.Config) iter.Seq2[taggedResource, error] {
+				return iamTaggedUsers(ctx, iam.NewFromConfig(conf))
+			},
+		},
+	}
+}
+
+// untaggedResourceSearchers returns, keyed by resource type, how to find resources that the Resource Groups Tagging
+// API only returns once they've been tagged.
+func untaggedResourceSearchers() map[string]nativeTagSearcher {
+	return map[string]nativeTagSearcher{
+		"ec2:instance": {
+			regional: true,
+			search: func(ctx context.Context, conf aws.Config) iter.Seq2[taggedResource, error] {
+				return ec2TaggedInstances(ctx, conf.Region, ec2.NewFromConfig(conf))
+			},
+		},
+		"ec2:vpc": {
+			regional: true,
+			search: func(ctx context.Context, conf aws.Config) iter.Seq2[taggedResource, error] {
+				return ec2TaggedVpcs(ctx, conf.Region, ec2.NewFromConfig(conf))
+			},
+		},
+		"ec2:vpc-endpoint": {
+			regional: true,
+			search: func(ctx context.Context, conf aws.Config) iter.Seq2[taggedResource, error] {
+				return ec2TaggedVpcEndpoints(ctx, conf.Region, ec2.NewFromConfig(conf))
+			},
+		},
+		"s3": {
+			search: func(ctx context.Context, conf aws.Config) iter.Seq2[taggedResource, error] {
+				return s3TaggedBuckets(ctx, conf.Region, s3.NewFromConfig(conf))
+			},
+		},
+	}
+}
+
+// nativeTagSearchersFor returns the native searchers for the resource types, which may just be the name of a service,
+// optionally including those that can find resources that have never been tagged.
+func nativeTagSearchersFor(resourceTypes []string, untagged bool) map[string]nativeTagSearcher {
+	searchers := nativeTagSearchers()
+	if untagged {
+		maps.Copy(searchers, untaggedResourceSearchers())
+	}
+	if len(resourceTypes) == 0 {
+		return searchers
+	}
+
+	maps.DeleteFunc(searchers, func(resourceType string, _ nativeTagSearcher) bool {
+		service, _, _ := strings.Cut(resourceType, ":")
+		return !slices.Contains(resourceTypes, resourceType) && !slices.Contains(resourceTypes, service)
+	})
+	return searchers
+}
+
+// arnResourceType returns the type of the resource in the same form as the Resource Groups Tagging API, such as
+// `iam:role`, or just the service if the ARN has no resource type.
+func arnResourceType(resourceARN string) string {
+	parsed, err := arn.Parse(resourceARN)
+	if err != nil {
+		return ""
+	}
+	resourceType, _, ok := strings.Cut(parsed.Resource, "/")
+	if !ok {
+		resourceType, _, ok = strings.Cut(parsed.Resource, ":")
+	}
+	if !ok {
+		return parsed.Service
+	}
+	return parsed.Service + ":" + resourceType
+}
+
+func findByTagNatively(
+	ctx context.Context, query tagQuery, resources iter.Seq2[taggedResource, error],
+) error {
+	for resource, err := range resources {
+		if err != nil {
+			return err
+		}
+
+		if matchesTagConditions(query.conditions, resource.tags) {
+			reportTaggedResource(ctx, query, resource.arn, resource.tags)
+		}
+	}
+
+	return nil
+}
+
+func cloudfrontTaggedDistributions(ctx context.Context, client cloudfrontLister) iter.Seq2[taggedResource, error] {
+	pages := cloudfront.NewListDistributionsPaginator(client, nil)
+
+	return map2(func(dist cloudfronttypes.DistributionSummary) (taggedResource, error) {
+		tags, err := cloudfrontDistributionTags(ctx, client, dist)
+		return taggedResource{arn: aws.ToString(dist.ARN), tags: tags}, err
+	}, paginatorToSeq(ctx, pages, cloudfrontListToItems))
+}
+
+func iamTaggedRoles(ctx context.Context, client iamRoleLister) iter.Seq2[taggedResource, error] {
+	pages := iam.NewListRolesPaginator(client, nil)
+
+	return map2(func(role iamtypes.Role) (taggedResource, error) {
+		tagPages := iam.NewListRoleTagsPaginator(client, &iam.ListRoleTagsInput{RoleName: role.RoleName})
+		tags, err := iamTags(paginatorToSeq(ctx, tagPages, func(r *iam.ListRoleTagsOutput) iter.Seq[iamtypes.Tag] {
+			return slices.Values(r.Tags)
+		}))
+		if err != nil {
+			return taggedResource{}, fmt.Errorf("failed to list tags for role %q: %w", aws.ToString(role.RoleName), err)
+		}
+		return taggedResource{arn: aws.ToString(role.Arn), tags: tags}, nil
+	}, paginatorToSeq(ctx, pages, func(r *iam.ListRolesOutput) iter.Seq[iamtypes.Role] {
+		return slices.Values(r.Roles)
+	}))
+}
+
+func iamTaggedUsers(ctx context.Context, client iamUserLister) iter.Seq2[taggedResource, error] {
+	pages := iam.NewListUsersPaginator(client, nil)
+
+	return map2(func(user iamtypes.User) (taggedResource, error) {
+		tagPages := iam.NewListUserTagsPaginator(client, &iam.ListUserTagsInput{UserName: user.UserName})
+		tags, err := iamTags(paginatorToSeq(ctx, tagPages, func(r *iam.ListUserTagsOutput) iter.Seq[iamtypes.Tag] {
+			return slices.Values(r.Tags)
+		}))
+		if err != nil {
+			return taggedResource{}, fmt.Errorf("failed to list tags for user %q: %w", aws.ToString(user.UserName), err)
+		}
+		return taggedResource{arn: aws.ToString(user.Arn), tags: tags}, nil
+	}, paginatorToSeq(ctx, pages, func(r *iam.ListUsersOutput) iter.Seq[iamtypes.User] {
+		return slices.Values(r.Users)
+	}))
+}
+
+func ec2TaggedInstances(
+	ctx context.Context, region string, client ec2.DescribeInstancesAPIClient,
+) iter.Seq2[taggedResource, error] {
+	pages := ec2.NewDescribeInstancesPaginator(client, nil)
+
+	return paginatorToSeq(ctx, pages, func(r *ec2.DescribeInstancesOutput) iter.Seq[taggedResource] {
+		var resources []taggedResource
+		for _, reservation := range r.Reservations {
+			for _, instance := range reservation.Instances {
+				resources = append(resources, taggedResource{
+					arn:  ec2ARN(region, reservation.OwnerId, "instance/"+aws.ToString(instance.InstanceId)),
+					tags: ec2Tags(instance.Tags),
+				})
+			}
+		}
+		return slices.Values(resources)
+	})
+}
+
+func ec2TaggedVpcs(
+	ctx context.Context, region string, client ec2.DescribeVpcsAPIClient,
+) iter.Seq2[taggedResource, error] {
+	pages := ec2.NewDescribeVpcsPaginator(client, nil)
+
+	return map2(func(vpc ec2types.Vpc) (taggedResource, error) {
+		return taggedResource{
+			arn:  ec2ARN(region, vpc.OwnerId, "vpc/"+aws.ToString(vpc.VpcId)),
+			tags: ec2Tags(vpc.Tags),
+		}, nil
+	}, paginatorToSeq(ctx, pages, vpcsToVpc))
+}
+
+func ec2TaggedVpcEndpoints(
+	ctx context.Context, region string, client ec2.DescribeVpcEndpointsAPIClient,
+) iter.Seq2[taggedResource, error] {
+	pages := ec2.NewDescribeVpcEndpointsPaginator(client, nil)
+
+	return map2(func(endpoint ec2types.VpcEndpoint) (taggedResource, error) {
+		return taggedResource{
+			arn:  ec2ARN(region, endpoint.OwnerId, "vpc-endpoint/"+aws.ToString(endpoint.VpcEndpointId)),
+			tags: ec2Tags(endpoint.Tags),
+		}, nil
+	}, paginatorToSeq(ctx, pages, vpcEndpointsToVpcEndpoint))
+}
+
+// ec2ARN builds the ARN of an EC2 resource, as the EC2 API only returns IDs.
+func ec2ARN(region string, owner *string, resource string) string {
+	return arn.ARN{
+		Partition: awsPartition(region),
+		Service:   "ec2",
+		Region:    region,
+		AccountID: aws.ToString(owner),
+		Resource:  resource,
+	}.String()
+}
+
+// awsPartition returns the partition of the region, as used in ARNs.
+func awsPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	default:
+		return "aws"
+	}
+}
+
+func ec2Tags(tags []ec2types.Tag) map[string]string {
+	ret := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		ret[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return ret
+}
+
+// s3TaggedBuckets lists the buckets in the partition of the region, as bucket ARNs include the partition but not the
+// region.
+func s3TaggedBuckets(ctx context.Context, region string, client s3TagLister) iter.Seq2[taggedResource, error] {
+	return map2(func(bucket s3types.Bucket) (taggedResource, error) {
+		tags, err := s3BucketTags(ctx, client, bucket)
+		if err != nil {
+			return taggedResource{}, err
+		}
+		return taggedResource{
+			arn:  arn.ARN{Partition: awsPartition(region), Service: "s3", Resource: aws.ToString(bucket.Name)}.String(),
+			tags: tags,
+		}, nil
+	}, s3Buckets(ctx, client, &s3.ListBucketsInput{}))
+}
+
+func iamTags(seq iter.Seq2[iamtypes.Tag, error]) (map[string]string, error) {
+	tags := map[string]string{}
+	for tag, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+type iamRoleLister interface {
+	iam.ListRolesAPIClient
+	iam.ListRoleTagsAPIClient
+}
+
+type iamUserLister interface {
+	iam.ListUsersAPIClient
+	iam.ListUserTagsAPIClient
+}
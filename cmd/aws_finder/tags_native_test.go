@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/iam/types"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func TestFindByTagNatively(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	conditions, err := parseTagQuery([]string{"env=prod", "AND", "NOT", "owner"})
+	require.NoError(t, err)
+
+	client := &iamEntities{
+		roles: []types.Role{
+			{RoleName: aws.String("untagged"), Arn: aws.String("arn:aws:iam::123456789012:role/untagged")},
+			{RoleName: aws.String("owned"), Arn: aws.String("arn:aws:iam::123456789012:role/owned")},
+			{RoleName: aws.String("found"), Arn: aws.String("arn:aws:iam::123456789012:role/found")},
+		},
+		users: []types.User{
+			{UserName: aws.String("found"), Arn: aws.String("arn:aws:iam::123456789012:user/found")},
+		},
+		tags: map[string][]types.Tag{
+			"owned": {
+				{Key: aws.String("env"), Value: aws.String("prod")},
+				{Key: aws.String("owner"), Value: aws.String("someone")},
+			},
+			"found": {
+				{Key: aws.String("env"), Value: aws.String("prod")},
+			},
+		},
+	}
+
+	query := tagQuery{conditions: conditions}
+	require.NoError(t, findByTagNatively(ctx, query, iamTaggedRoles(ctx, client)))
+	require.NoError(t, findByTagNatively(ctx, query, iamTaggedUsers(ctx, client)))
+
+	assert.Equal(t, `level=INFO msg=arn:aws:iam::123456789012:role/found tags.env=prod
+level=INFO msg=arn:aws:iam::123456789012:user/found tags.env=prod
+`, buf.String())
+}
+
+func TestFindByTagNatively_Missing(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	client := &instances{
+		reservations: [][]ec2types.Reservation{
+			{
+				{
+					OwnerId: aws.String("123456789012"),
+					Instances: []ec2types.Instance{
+						{
+							InstanceId: aws.String("i-untagged"),
+						},
+						{
+							InstanceId: aws.String("i-compliant"),
+							Tags: []ec2types.Tag{
+								{Key: aws.String("owner"), Value: aws.String("someone")},
+								{Key: aws.String("team"), Value: aws.String("payments")},
+							},
+						},
+					},
+				},
+			},
+			{
+				{
+					OwnerId: aws.String("210987654321"),
+					Instances: []ec2types.Instance{
+						{
+							InstanceId: aws.String("i-ownerless"),
+							Tags: []ec2types.Tag{
+								{Key: aws.String("team"), Value: aws.String("payments")},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	query := tagQuery{missing: []string{"owner", "team"}, showTags: []string{"team"}}
+	require.NoError(t, findByTagNatively(ctx, query, ec2TaggedInstances(ctx, "cn-north-1", client)))
+
+	assert.Equal(t, `level=INFO msg=arn:aws-cn:ec2:cn-north-1:123456789012:instance/i-untagged missing=owner,team
+level=INFO msg=arn:aws-cn:ec2:cn-north-1:210987654321:instance/i-ownerless tags.team=payments missing=owner
+`, buf.String())
+}
+
+func TestS3TaggedBuckets(t *testing.T) {
+	var tests = []struct {
+		region    string
+		partition string
+	}{
+		{"eu-west-1", "aws"},
+		{"cn-north-1", "aws-cn"},
+		{"us-gov-west-1", "aws-us-gov"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.region, func(t *testing.T) {
+			client := &buckets{
+				buckets: []s3types.Bucket{
+					{Name: aws.String("untagged")},
+					{Name: aws.String("tagged"), BucketRegion: aws.String(test.region)},
+				},
+				bucketTags: map[string][]s3types.Tag{
+					"tagged": {{Key: aws.String("owner"), Value: aws.String("someone")}},
+				},
+			}
+
+			var resources []taggedResource
+			for resource, err := range s3TaggedBuckets(t.Context(), test.region, client) {
+				require.NoError(t, err)
+				resources = append(resources, resource)
+			}
+
+			assert.Equal(t, []taggedResource{
+				{arn: "arn:" + test.partition + ":s3:::untagged", tags: map[string]string{}},
+				{arn: "arn:" + test.partition + ":s3:::tagged", tags: map[string]string{"owner": "someone"}},
+			}, resources)
+		})
+	}
+}
+
+func TestNativeTagSearchersFor(t *testing.T) {
+	var tests = []struct {
+		resourceTypes []string
+		untagged      bool
+		expected      []string
+	}{
+		{nil, false, []string{"cloudfront:distribution", "iam:role", "iam:user"}},
+		{[]string{"iam"}, false, []string{"iam:role", "iam:user"}},
+		{[]string{"ec2:instance", "iam:role"}, false, []string{"iam:role"}},
+		{[]string{"ec2:instance", "s3"}, false, nil},
+		{[]string{"ec2:instance", "s3"}, true, []string{"ec2:instance", "s3"}},
+		{
+			[]string{"ec2", "cloudfront"},
+			true,
+			[]string{"cloudfront:distribution", "ec2:instance", "ec2:vpc", "ec2:vpc-endpoint"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("", func(t *testing.T) {
+			searchers := nativeTagSearchersFor(test.resourceTypes, test.untagged)
+			assert.Equal(t, test.expected, slices.Sorted(maps.Keys(searchers)))
+		})
+	}
+}
+
+func TestArnResourceType(t *testing.T) {
+	assert.Equal(t, "iam:role", arnResourceType("arn:aws:iam::123456789012:role/path/name"))
+	assert.Equal(t, "cloudfront:distribution", arnResourceType("arn:aws:cloudfront::123456789012:distribution/E1"))
+	assert.Equal(t, "logs:log-group", arnResourceType("arn:aws:logs:eu-west-1:123456789012:log-group:name"))
+	assert.Equal(t, "s3", arnResourceType("arn:aws:s3:::bucket"))
+	assert.Empty(t, arnResourceType("not an arn"))
+}
+
+var (
+	_ iamRoleLister = &iamEntities{}
+	_ iamUserLister = &iamEntities{}
+)
+
+type iamEntities struct {
+	roles []types.Role
+	users []types.User
+	tags  map[string][]types.Tag
+}
+
+func (i *iamEntities) ListRoles(
+	ctx context.Context, _ *iam.ListRolesInput, _ ...func(*iam.Options),
+) (*iam.ListRolesOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	return &iam.ListRolesOutput{Roles: i.roles}, nil
+}
+
+func (i *iamEntities) ListRoleTags(
+	ctx context.Context, params *iam.ListRoleTagsInput, _ ...func(*iam.Options),
+) (*iam.ListRoleTagsOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	return &iam.ListRoleTagsOutput{Tags: i.tags[aws.ToString(params.RoleName)]}, nil
+}
+
+func (i *iamEntities) ListUsers(
+	ctx context.Context, _ *iam.ListUsersInput, _ ...func(*iam.Options),
+) (*iam.ListUsersOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	return &iam.ListUsersOutput{Users: i.users}, nil
+}
+
+func (i *iamEntities) ListUserTags(
+	ctx context.Context, params *iam.ListUserTagsInput, _ ...func(*iam.Options),
+) (*iam.ListUserTagsOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	return &iam.ListUserTagsOutput{Tags: i.tags[aws.ToString(params.UserName)]}, nil
+}
@@ -27,10 +27,17 @@ func TestFindByTag(t *testing.T) {
 		}),
 	}))
 
+	conditions, err := parseTagQuery([]string{"tag-key", "value1", "value2"})
+	require.NoError(t, err)
+
 	require.NoError(t, findByTag(ctx, &resourceTagLister{
-		t:      t,
-		key:    "tag-key",
-		values: []string{"value1", "value2"},
+		t: t,
+		filters: []types.TagFilter{
+			{
+				Key:    aws.String("tag-key"),
+				Values: []string{"value1", "value2"},
+			},
+		},
 		resources: [][]types.ResourceTagMapping{
 			{
 				{
@@ -38,17 +45,257 @@ func TestFindByTag(t *testing.T) {
 				},
 			},
 		},
-	}, "tag-key", "value1", "value2"))
+	}, tagQuery{conditions: conditions}))
 
 	assert.Equal(t, "level=INFO msg=expected\n", buf.String())
 }
 
+func TestFindByTag_Query(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	conditions, err := parseTagQuery([]string{"env=prod", "AND", "team=pay*", "AND", "NOT", "owner"})
+	require.NoError(t, err)
+
+	require.NoError(t, findByTag(ctx, &resourceTagLister{
+		t: t,
+		filters: []types.TagFilter{
+			{Key: aws.String("env"), Values: []string{"prod"}},
+			{Key: aws.String("team")},
+		},
+		resources: [][]types.ResourceTagMapping{
+			{
+				{
+					ResourceARN: aws.String("wrong team"),
+					Tags: []types.Tag{
+						{Key: aws.String("env"), Value: aws.String("prod")},
+						{Key: aws.String("team"), Value: aws.String("platform")},
+					},
+				},
+				{
+					ResourceARN: aws.String("has owner"),
+					Tags: []types.Tag{
+						{Key: aws.String("env"), Value: aws.String("prod")},
+						{Key: aws.String("team"), Value: aws.String("payments")},
+						{Key: aws.String("owner"), Value: aws.String("someone")},
+					},
+				},
+			},
+			{
+				{
+					ResourceARN: aws.String("expected"),
+					Tags: []types.Tag{
+						{Key: aws.String("env"), Value: aws.String("prod")},
+						{Key: aws.String("team"), Value: aws.String("payments")},
+					},
+				},
+			},
+		},
+	}, tagQuery{conditions: conditions}))
+
+	assert.Equal(t, "level=INFO msg=expected tags.env=prod tags.team=payments\n", buf.String())
+}
+
+func TestFindByTag_ResourceTypesAndShownTags(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	conditions, err := parseTagQuery([]string{"env"})
+	require.NoError(t, err)
+
+	require.NoError(t, findByTag(ctx, &resourceTagLister{
+		t:             t,
+		filters:       []types.TagFilter{{Key: aws.String("env")}},
+		resourceTypes: []string{"ec2:instance", "s3"},
+		resources: [][]types.ResourceTagMapping{
+			{
+				{
+					ResourceARN: aws.String("expected"),
+					Tags: []types.Tag{
+						{Key: aws.String("env"), Value: aws.String("prod")},
+						{Key: aws.String("team"), Value: aws.String("payments")},
+						{Key: aws.String("owner"), Value: aws.String("someone")},
+					},
+				},
+			},
+		},
+	}, tagQuery{
+		conditions:    conditions,
+		resourceTypes: []string{"ec2:instance", "s3"},
+		showTags:      []string{"owner", "missing", "env"},
+	}))
+
+	assert.Equal(t, "level=INFO msg=expected tags.owner=someone tags.env=prod\n", buf.String())
+}
+
+func TestFindByTag_IgnoresNativeTypes(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	conditions, err := parseTagQuery([]string{"env"})
+	require.NoError(t, err)
+
+	require.NoError(t, findByTag(ctx, &resourceTagLister{
+		t:       t,
+		filters: []types.TagFilter{{Key: aws.String("env")}},
+		resources: [][]types.ResourceTagMapping{
+			{
+				{
+					ResourceARN: aws.String("arn:aws:cloudfront::123456789012:distribution/E1"),
+				},
+				{
+					ResourceARN: aws.String("arn:aws:ec2:eu-west-1:123456789012:instance/i-1"),
+				},
+			},
+		},
+	}, tagQuery{conditions: conditions, nativeTypes: []string{"cloudfront:distribution"}}))
+
+	assert.Equal(t, "level=INFO msg=arn:aws:ec2:eu-west-1:123456789012:instance/i-1\n", buf.String())
+}
+
+func TestFindByTag_Missing(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	require.NoError(t, findByTag(ctx, &resourceTagLister{
+		t: t,
+		resources: [][]types.ResourceTagMapping{
+			{
+				{
+					ResourceARN: aws.String("compliant"),
+					Tags: []types.Tag{
+						{Key: aws.String("owner"), Value: aws.String("someone")},
+						{Key: aws.String("team"), Value: aws.String("payments")},
+					},
+				},
+				{
+					ResourceARN: aws.String("missing-owner"),
+					Tags: []types.Tag{
+						{Key: aws.String("team"), Value: aws.String("payments")},
+					},
+				},
+			},
+		},
+	}, tagQuery{missing: []string{"owner", "team"}}))
+
+	assert.Equal(t, "level=INFO msg=missing-owner tags.team=payments missing=owner\n", buf.String())
+}
+
+func TestParseTagQuery(t *testing.T) {
+	var tests = []struct {
+		args     []string
+		expected []types.TagFilter
+		exact    []bool
+	}{
+		{
+			[]string{"env"},
+			[]types.TagFilter{{Key: aws.String("env")}},
+			[]bool{true},
+		},
+		{
+			[]string{"env", "prod", "dev"},
+			[]types.TagFilter{{Key: aws.String("env"), Values: []string{"prod", "dev"}}},
+			[]bool{true},
+		},
+		{
+			[]string{"env=prod", "AND", "team"},
+			[]types.TagFilter{{Key: aws.String("env"), Values: []string{"prod"}}, {Key: aws.String("team")}},
+			[]bool{true, true},
+		},
+		{
+			[]string{"NOT", "env=prod", "AND", "team=*-api"},
+			[]types.TagFilter{{Key: aws.String("team")}},
+			[]bool{false, false},
+		},
+		{
+			[]string{"and", "not"},
+			[]types.TagFilter{{Key: aws.String("and"), Values: []string{"not"}}},
+			[]bool{true},
+		},
+		{
+			[]string{"NOT", "and", "AND", "not=*"},
+			[]types.TagFilter{{Key: aws.String("not")}},
+			[]bool{false, false},
+		},
+		{
+			[]string{"env", "prod-?"},
+			[]types.TagFilter{{Key: aws.String("env")}},
+			[]bool{false},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.args[0], func(t *testing.T) {
+			conditions, err := parseTagQuery(test.args)
+			require.NoError(t, err)
+
+			var filters []types.TagFilter
+			var exact []bool
+			for _, condition := range conditions {
+				filter, ok := condition.tagFilter()
+				if filter != nil {
+					filters = append(filters, *filter)
+				}
+				exact = append(exact, ok)
+			}
+			assert.Equal(t, test.expected, filters)
+			assert.Equal(t, test.exact, exact)
+		})
+	}
+}
+
+func TestParseTagQuery_Invalid(t *testing.T) {
+	var tests = []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"AND", "env"}, "unexpected AND in tag query"},
+		{[]string{"env", "AND"}, "incomplete tag query"},
+		{[]string{"env", "NOT", "team"}, "unexpected NOT in tag query"},
+		{[]string{"NOT", "NOT", "team"}, "unexpected NOT in tag query"},
+		{[]string{"env=prod", "team"}, `expected AND before "team" in tag query`},
+		{[]string{"env=prod", "and", "team"}, `expected AND before "and" in tag query`},
+		{[]string{"=prod"}, "tag filter must have a key"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			_, err := parseTagQuery(test.args)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
 var _ resourcegroupstaggingapi.GetResourcesAPIClient = &resourceTagLister{}
 
 type resourceTagLister struct {
-	t      *testing.T
-	key    string
-	values []string
+	t             *testing.T
+	filters       []types.TagFilter
+	resourceTypes []string
 
 	resources [][]types.ResourceTagMapping
 }
@@ -61,13 +308,13 @@ func (r *resourceTagLister) GetResources(
 	if ctx == nil {
 		return nil, errors.New("missing context")
 	}
-	if aws.ToBool(input.ExcludeCompliantResources) || len(input.ResourceTypeFilters) != 0 {
+	if aws.ToBool(input.ExcludeCompliantResources) {
 		return nil, errors.New("unexpected input")
 	}
-	if !assert.ElementsMatch(r.t, r.values, input.TagFilters[0].Values) {
-		return nil, errors.New("invalid values")
+	if !assert.ElementsMatch(r.t, r.resourceTypes, input.ResourceTypeFilters) {
+		return nil, errors.New("invalid resource types")
 	}
-	if len(input.TagFilters) != 1 || aws.ToString(input.TagFilters[0].Key) != r.key {
+	if !assert.ElementsMatch(r.t, r.filters, input.TagFilters) {
 		return nil, errors.New("invalid input")
 	}
 
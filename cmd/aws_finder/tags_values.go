@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/spf13/cobra"
+	"github.com/wjam/aws_finder/internal/finder"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func tagValuesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "values <key>",
+		Short: "Count the resources using each value of a tag key, per account",
+		Long: `Count the resources using each value of a tag key, per account.
+
+Keys that only differ from the given key by case or punctuation, such as "CostCentre" or "cost_centre" for
+"cost-centre", are counted separately so that inconsistently tagged resources can be spotted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inventory := &tagInventory{}
+			tags := tagFiltersFromContext(cmd.Context())
+			searchers := nativeTagSearchers()
+			nativeTypes := slices.Sorted(maps.Keys(searchers))
+
+			if err := finder.SearchPerRegion(
+				cmd.Context(),
+				func(ctx context.Context, conf aws.Config) error {
+					return inventoryTagValues(
+						ctx,
+						args[0],
+						nativeTypes,
+						tags,
+						inventory,
+						resourcegroupstaggingapi.NewFromConfig(conf),
+						sts.NewFromConfig(conf),
+					)
+				}); err != nil {
+				return err
+			}
+			if err := finder.SearchPerProfile(
+				cmd.Context(),
+				func(ctx context.Context, conf aws.Config) error {
+					for _, resourceType := range nativeTypes {
+						resources := searchers[resourceType].search(ctx, conf)
+						if err := inventoryTagValuesNatively(args[0], tags, inventory, resources); err != nil {
+							return fmt.Errorf("failed to search %s: %w", resourceType, err)
+						}
+					}
+					return nil
+				}); err != nil {
+				return err
+			}
+
+			inventory.report(cmd.Context())
+			return nil
+		},
+	}
+}
+
+// inventoryTagValues counts the values of the key, and those similar to it, on resources returned by the tagging API,
+// ignoring the resource types that are searched natively. Every resource is listed, as GetTagValues only returns the
+// distinct values rather than how many resources in each account use them.
+func inventoryTagValues(
+	ctx context.Context,
+	key string,
+	nativeTypes []string,
+	tags []tagFilter,
+	inventory *tagInventory,
+	client tagValuesLister,
+	identity callerIdentityGetter,
+) error {
+	keys, err := similarTagKeys(ctx, key, client)
+	if err != nil {
+		return err
+	}
+
+	var account string
+	for _, k := range keys {
+		pages := resourcegroupstaggingapi.NewGetResourcesPaginator(client, &resourcegroupstaggingapi.GetResourcesInput{
+			TagFilters: []types.TagFilter{{Key: aws.String(k)}},
+		})
+
+		for resource, err := range paginatorToSeq(ctx, pages, tagMappingListToResource) {
+			if err != nil {
+				return err
+			}
+
+			resourceARN := aws.ToString(resource.ResourceARN)
+			if slices.Contains(nativeTypes, arnResourceType(resourceARN)) {
+				continue
+			}
+			resourceTags := resourceTags(resource)
+			if !matchesTagFilters(tags, resourceTags) {
+				continue
+			}
+
+			// Some ARNs, such as for S3 buckets, don't include the account
+			resourceAccount := arnAccount(resourceARN)
+			if resourceAccount == "" {
+				if account == "" {
+					if account, err = callerAccount(ctx, identity); err != nil {
+						return err
+					}
+				}
+				resourceAccount = account
+			}
+
+			inventory.add(k, resourceTags[k], resourceAccount)
+		}
+	}
+
+	return nil
+}
+
+func inventoryTagValuesNatively(
+	key string, tags []tagFilter, inventory *tagInventory, resources iter.Seq2[taggedResource, error],
+) error {
+	for resource, err := range resources {
+		if err != nil {
+			return err
+		}
+		if !matchesTagFilters(tags, resource.tags) {
+			continue
+		}
+
+		for k, value := range resource.tags {
+			if normaliseTagKey(k) == normaliseTagKey(key) {
+				inventory.add(k, value, arnAccount(resource.arn))
+			}
+		}
+	}
+	return nil
+}
+
+// similarTagKeys returns the tag keys in use that match the key once case and punctuation is ignored.
+func similarTagKeys(
+	ctx context.Context, key string, client resourcegroupstaggingapi.GetTagKeysAPIClient,
+) ([]string, error) {
+	pages := resourcegroupstaggingapi.NewGetTagKeysPaginator(client, nil)
+
+	var keys []string
+	for k, err := range paginatorToSeq(ctx, pages, tagKeysToKey) {
+		if err != nil {
+			return nil, err
+		}
+		if normaliseTagKey(k) == normaliseTagKey(key) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func tagKeysToKey(r *resourcegroupstaggingapi.GetTagKeysOutput) iter.Seq[string] {
+	return slices.Values(r.TagKeys)
+}
+
+// normaliseTagKey ignores case and punctuation, so that keys such as "CostCentre" and "cost-centre" are the same.
+func normaliseTagKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" -_.:/", r) {
+			return -1
+		}
+		return r
+	}, strings.ToLower(key))
+}
+
+func arnAccount(resourceARN string) string {
+	parsed, err := arn.Parse(resourceARN)
+	if err != nil {
+		return ""
+	}
+	return parsed.AccountID
+}
+
+func callerAccount(ctx context.Context, client callerIdentityGetter) (string, error) {
+	identity, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to lookup account: %w", err)
+	}
+	return aws.ToString(identity.Account), nil
+}
+
+// tagInventory counts the resources using each tag value per account, shared between every profile and region.
+type tagInventory struct {
+	lock sync.Mutex
+	// counts are keyed by tag key, then value and then account.
+	counts map[string]map[string]map[string]int
+}
+
+func (i *tagInventory) add(key, value, account string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.counts == nil {
+		i.counts = map[string]map[string]map[string]int{}
+	}
+	if i.counts[key] == nil {
+		i.counts[key] = map[string]map[string]int{}
+	}
+	if i.counts[key][value] == nil {
+		i.counts[key][value] = map[string]int{}
+	}
+	i.counts[key][value][account]++
+}
+
+func (i *tagInventory) report(ctx context.Context) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, key := range slices.Sorted(maps.Keys(i.counts)) {
+		for _, value := range slices.Sorted(maps.Keys(i.counts[key])) {
+			accounts := i.counts[key][value]
+
+			total := 0
+			var attrs []any
+			for _, account := range slices.Sorted(maps.Keys(accounts)) {
+				total += accounts[account]
+				attrs = append(attrs, slog.Int(account, accounts[account]))
+			}
+
+			log.Logger(ctx).InfoContext(
+				ctx, value, slog.String("key", key), slog.Int("total", total), slog.Group("accounts", attrs...),
+			)
+		}
+	}
+}
+
+type tagValuesLister interface {
+	resourcegroupstaggingapi.GetResourcesAPIClient
+	resourcegroupstaggingapi.GetTagKeysAPIClient
+}
+
+type callerIdentityGetter interface {
+	GetCallerIdentity(
+		ctx context.Context, params *sts.GetCallerIdentityInput, optFns ...func(*sts.Options),
+	) (*sts.GetCallerIdentityOutput, error)
+}
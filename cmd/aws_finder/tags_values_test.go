@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	iamtypes "github.com/aws/aws-sdk-go-v2/service/iam/types"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func TestInventoryTagValues(t *testing.T) {
+	var tests = []struct {
+		name     string
+		tags     []tagFilter
+		expected string
+	}{
+		{
+			"all",
+			nil,
+			"level=INFO msg=payments key=T-E-A-M total=1 accounts.123456789012=1\n" +
+				"level=INFO msg=payments key=Team total=1 accounts.123456789012=1\n" +
+				"level=INFO msg=payments key=team total=3 accounts.123456789012=2 accounts.210987654321=1\n" +
+				"level=INFO msg=search key=team total=1 accounts.123456789012=1\n",
+		},
+		{
+			"global-tag-filter",
+			[]tagFilter{mustParseTagFilter(t, "env=prod")},
+			"level=INFO msg=payments key=team total=1 accounts.123456789012=1\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			inventory := &tagInventory{}
+			require.NoError(t, inventoryTagValues(
+				ctx,
+				"team",
+				[]string{"iam:role"},
+				test.tags,
+				inventory,
+				&taggedResources{
+					keys: []string{"env", "Team", "team", "T-E-A-M", "teams"},
+					resources: []types.ResourceTagMapping{
+						tagMapping(
+							"arn:aws:ec2:eu-west-1:123456789012:instance/i-1", "team", "payments", "env", "prod",
+						),
+						tagMapping("arn:aws:ec2:eu-west-1:123456789012:vpc/vpc-1", "team", "search"),
+						tagMapping("arn:aws:ec2:eu-west-1:123456789012:vpc/vpc-2", "Team", "payments"),
+						tagMapping("arn:aws:ec2:eu-west-1:123456789012:vpc/vpc-3", "T-E-A-M", "payments"),
+						tagMapping("arn:aws:ec2:eu-west-1:123456789012:vpc/vpc-4", "teams", "payments"),
+						tagMapping("arn:aws:iam::123456789012:role/admin", "team", "payments"),
+						tagMapping("arn:aws:s3:::bucket", "team", "payments"),
+					},
+				},
+				&callerIdentity{account: "123456789012"},
+			))
+			require.NoError(t, inventoryTagValuesNatively(
+				"team",
+				test.tags,
+				inventory,
+				iamTaggedRoles(ctx, &iamEntities{
+					roles: []iamtypes.Role{
+						{RoleName: aws.String("admin"), Arn: aws.String("arn:aws:iam::210987654321:role/admin")},
+					},
+					tags: map[string][]iamtypes.Tag{
+						"admin": {{Key: aws.String("team"), Value: aws.String("payments")}},
+					},
+				}),
+			))
+
+			inventory.report(ctx)
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestNormaliseTagKey(t *testing.T) {
+	assert.Equal(t, "costcentre", normaliseTagKey("Cost-Centre"))
+	assert.Equal(t, "costcentre", normaliseTagKey("cost_centre"))
+	assert.Equal(t, "costcentre", normaliseTagKey("cost centre"))
+}
+
+func mustParseTagFilter(t *testing.T, s string) tagFilter {
+	t.Helper()
+	filter, err := parseTagFilter(s)
+	require.NoError(t, err)
+	return filter
+}
+
+func tagMapping(arn string, tags ...string) types.ResourceTagMapping {
+	mapping := types.ResourceTagMapping{ResourceARN: aws.String(arn)}
+	for i := 0; i < len(tags); i += 2 {
+		mapping.Tags = append(mapping.Tags, types.Tag{Key: aws.String(tags[i]), Value: aws.String(tags[i+1])})
+	}
+	return mapping
+}
+
+var (
+	_ tagValuesLister      = &taggedResources{}
+	_ callerIdentityGetter = &callerIdentity{}
+)
+
+type taggedResources struct {
+	keys      []string
+	resources []types.ResourceTagMapping
+}
+
+func (r *taggedResources) GetTagKeys(
+	ctx context.Context, _ *resourcegroupstaggingapi.GetTagKeysInput, _ ...func(*resourcegroupstaggingapi.Options),
+) (*resourcegroupstaggingapi.GetTagKeysOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+
+	return &resourcegroupstaggingapi.GetTagKeysOutput{TagKeys: r.keys}, nil
+}
+
+func (r *taggedResources) GetResources(
+	ctx context.Context,
+	input *resourcegroupstaggingapi.GetResourcesInput,
+	_ ...func(*resourcegroupstaggingapi.Options),
+) (*resourcegroupstaggingapi.GetResourcesOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if len(input.TagFilters) != 1 || len(input.TagFilters[0].Values) != 0 {
+		return nil, errors.New("invalid input")
+	}
+
+	var resources []types.ResourceTagMapping
+	for _, resource := range r.resources {
+		if _, ok := resourceTags(resource)[aws.ToString(input.TagFilters[0].Key)]; ok {
+			resources = append(resources, resource)
+		}
+	}
+	return &resourcegroupstaggingapi.GetResourcesOutput{ResourceTagMappingList: resources}, nil
+}
+
+type callerIdentity struct {
+	account string
+}
+
+func (c *callerIdentity) GetCallerIdentity(
+	ctx context.Context, _ *sts.GetCallerIdentityInput, _ ...func(*sts.Options),
+) (*sts.GetCallerIdentityOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+
+	return &sts.GetCallerIdentityOutput{Account: aws.String(c.account)}, nil
+}
@@ -20,21 +20,25 @@ func vpcCmd() *cobra.Command {
 		Short: "Find a VPC with the given CIDR range",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			tags := tagFiltersFromContext(cmd.Context())
 			return finder.SearchPerRegion(
 				cmd.Context(),
 				func(ctx context.Context, conf aws.Config) error {
-					return findVpc(ctx, args[0], ec2.NewFromConfig(conf))
+					return findVpc(ctx, args[0], tags, ec2.NewFromConfig(conf))
 				})
 		},
 	}
 }
 
-func findVpc(ctx context.Context, needle string, client ec2.DescribeVpcsAPIClient) error {
-	pages := ec2.NewDescribeVpcsPaginator(client, nil)
+func findVpc(ctx context.Context, needle string, tags []tagFilter, client ec2.DescribeVpcsAPIClient) error {
+	filters := ec2NeedleFilters(needle, ec2IDFilter("vpc-id", "vpc"), ec2CidrFilter("cidr"), ec2TagFilter)
+	pages := ec2.NewDescribeVpcsPaginator(client, &ec2.DescribeVpcsInput{
+		Filters: append(ec2TagFilters(tags), filters...),
+	})
 
 	seq := paginatorToSeq(ctx, pages, vpcsToVpc)
 	seq = filter2(func(vpc types.Vpc, err error) bool {
-		return err != nil || strings.Contains(*vpc.CidrBlock, needle)
+		return err != nil || len(filters) != 0 || strings.Contains(aws.ToString(vpc.CidrBlock), needle)
 	}, seq)
 
 	for vpc, err := range seq {
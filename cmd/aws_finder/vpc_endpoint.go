@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"iter"
+	"log/slog"
 	"slices"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
@@ -14,46 +17,226 @@ import (
 )
 
 func vpcEndpointCmd() *cobra.Command {
-	return &cobra.Command{
+	var query vpcEndpointQuery
+	cmd := &cobra.Command{
 		Use:   "vpc_endpoint [needle]",
 		Short: "Find a VPC endpoint by the given service name",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := query.validate(); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				query.needle = args[0]
+			}
+			query.tags = tagFiltersFromContext(cmd.Context())
 			return finder.SearchPerRegion(
 				cmd.Context(),
 				func(ctx context.Context, conf aws.Config) error {
-					return findVpcEndpoints(ctx, args[0], ec2.NewFromConfig(conf))
+					return findVpcEndpoints(ctx, query, ec2.NewFromConfig(conf))
 				})
 		},
 	}
+	cmd.Flags().StringVar(&query.vpcID, "vpc", "", "Only find endpoints in this VPC")
+	cmd.Flags().StringVar(
+		&query.endpointType,
+		"type",
+		"",
+		fmt.Sprintf(
+			"Only find endpoints of this type (%s)",
+			strings.Join(enumStrings(types.VpcEndpointType("").Values()), ", "),
+		),
+	)
+	cmd.Flags().StringVar(
+		&query.state,
+		"state",
+		"",
+		fmt.Sprintf(
+			"Only find endpoints in this state (%s)",
+			strings.Join(enumStrings(types.State("").Values()), ", "),
+		),
+	)
+	cmd.Flags().StringVar(&query.subnet, "subnet", "", "Only find endpoints with a network interface in this subnet")
+	cmd.Flags().StringVar(
+		&query.securityGroup, "security-group", "", "Only find endpoints using this security group ID or name",
+	)
+	cmd.Flags().StringVar(
+		&query.principal,
+		"principal",
+		"",
+		"Only find endpoints whose policy allows this account ID or ARN, or * for any principal",
+	)
+	return cmd
 }
 
-func findVpcEndpoints(
-	ctx context.Context, needle string, client ec2.DescribeVpcEndpointsAPIClient,
-) error {
-	pages := ec2.NewDescribeVpcEndpointsPaginator(client, nil)
+type vpcEndpointQuery struct {
+	needle        string
+	vpcID         string
+	endpointType  string
+	state         string
+	subnet        string
+	securityGroup string
+	principal     string
+	tags          []tagFilter
+}
 
-	seq := paginatorToSeq(ctx, pages, vpcEndpointsToVpcEndpoint)
-	seq = filter2(func(endpoint types.VpcEndpoint, err error) bool {
-		return err != nil || findVpcEndpoint(needle, endpoint)
-	}, seq)
+func (q vpcEndpointQuery) validate() error {
+	if q.endpointType != "" && !slices.Contains(enumStrings(types.VpcEndpointType("").Values()), q.endpointType) {
+		return fmt.Errorf("unknown VPC endpoint type %q", q.endpointType)
+	}
+	if q.state != "" && !slices.ContainsFunc(enumStrings(types.State("").Values()), func(state string) bool {
+		return strings.EqualFold(state, q.state)
+	}) {
+		return fmt.Errorf("unknown VPC endpoint state %q", q.state)
+	}
+	return nil
+}
+
+// filters returns the parts of the query that DescribeVpcEndpoints can apply server-side. The state isn't pushed down
+// as the API doesn't use the same case for it as the SDK.
+func (q vpcEndpointQuery) filters() []types.Filter {
+	var filters []types.Filter
+	if q.vpcID != "" {
+		filters = append(filters, types.Filter{Name: aws.String("vpc-id"), Values: []string{q.vpcID}})
+	}
+	if q.endpointType != "" {
+		filters = append(filters, types.Filter{Name: aws.String("vpc-endpoint-type"), Values: []string{q.endpointType}})
+	}
+	return append(filters, ec2TagFilters(q.tags)...)
+}
+
+func (q vpcEndpointQuery) matchesFilters(endpoint types.VpcEndpoint) (bool, error) {
+	if q.vpcID != "" && aws.ToString(endpoint.VpcId) != q.vpcID {
+		return false, nil
+	}
+	if q.endpointType != "" && string(endpoint.VpcEndpointType) != q.endpointType {
+		return false, nil
+	}
+	if q.state != "" && !strings.EqualFold(string(endpoint.State), q.state) {
+		return false, nil
+	}
+	if q.subnet != "" && !slices.Contains(endpoint.SubnetIds, q.subnet) {
+		return false, nil
+	}
+	if q.securityGroup != "" && !slices.ContainsFunc(endpoint.Groups, func(group types.SecurityGroupIdentifier) bool {
+		return aws.ToString(group.GroupId) == q.securityGroup || aws.ToString(group.GroupName) == q.securityGroup
+	}) {
+		return false, nil
+	}
+	if q.principal == "" {
+		return true, nil
+	}
+	principals, err := vpcEndpointPrincipals(endpoint)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(principals, func(principal string) bool {
+		return principalMatches(principal, q.principal)
+	}), nil
+}
+
+func findVpcEndpoints(ctx context.Context, query vpcEndpointQuery, client ec2.DescribeVpcEndpointsAPIClient) error {
+	for endpoint, err := range matchingVpcEndpoints(ctx, query, client) {
+		if err != nil {
+			return err
+		}
 
-	for endpoint, err := range seq {
+		attrs, err := vpcEndpointAttrs(endpoint)
 		if err != nil {
 			return err
 		}
-		log.Logger(ctx).InfoContext(ctx, aws.ToString(endpoint.VpcEndpointId))
+		log.Logger(ctx).InfoContext(ctx, aws.ToString(endpoint.VpcEndpointId), attrs...)
 	}
 
 	return nil
 }
 
+// matchingVpcEndpoints returns the endpoints matching the query.
+func matchingVpcEndpoints(
+	ctx context.Context, query vpcEndpointQuery, client ec2.DescribeVpcEndpointsAPIClient,
+) iter.Seq2[types.VpcEndpoint, error] {
+	// The needle isn't pushed down, as it's matched against several fields and filtering on one would miss the others
+	pages := ec2.NewDescribeVpcEndpointsPaginator(client, &ec2.DescribeVpcEndpointsInput{
+		Filters: query.filters(),
+	})
+
+	seq := paginatorToSeq(ctx, pages, vpcEndpointsToVpcEndpoint)
+	seq = filter2(func(endpoint types.VpcEndpoint, err error) bool {
+		return err != nil || findVpcEndpoint(query.needle, endpoint)
+	}, seq)
+
+	return func(yield func(types.VpcEndpoint, error) bool) {
+		for endpoint, err := range seq {
+			if err != nil {
+				yield(endpoint, err)
+				return
+			}
+
+			matched, err := query.matchesFilters(endpoint)
+			if err != nil {
+				yield(endpoint, err)
+				return
+			}
+			if matched && !yield(endpoint, nil) {
+				return
+			}
+		}
+	}
+}
+
+func vpcEndpointAttrs(endpoint types.VpcEndpoint) ([]any, error) {
+	var attrs []any
+	if endpoint.VpcId != nil {
+		attrs = append(attrs, slog.String("vpc", aws.ToString(endpoint.VpcId)))
+	}
+	if endpoint.VpcEndpointType != "" {
+		attrs = append(attrs, slog.String("type", string(endpoint.VpcEndpointType)))
+	}
+	if endpoint.State != "" {
+		attrs = append(attrs, slog.String("state", string(endpoint.State)))
+	}
+	if len(endpoint.SubnetIds) != 0 {
+		attrs = append(attrs, slog.String("subnets", strings.Join(endpoint.SubnetIds, ",")))
+	}
+	if len(endpoint.Groups) != 0 {
+		groups := make([]string, 0, len(endpoint.Groups))
+		for _, group := range endpoint.Groups {
+			groups = append(groups, aws.ToString(group.GroupId))
+		}
+		attrs = append(attrs, slog.String("security-groups", strings.Join(groups, ",")))
+	}
+	principals, err := vpcEndpointPrincipals(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if len(principals) != 0 {
+		attrs = append(attrs, slog.String("principals", strings.Join(principals, ",")))
+	}
+	return attrs, nil
+}
+
+// vpcEndpointPrincipals returns the principals allowed by the endpoint policy. Only gateway and interface endpoints
+// have a policy.
+func vpcEndpointPrincipals(endpoint types.VpcEndpoint) ([]string, error) {
+	if aws.ToString(endpoint.PolicyDocument) == "" {
+		return nil, nil
+	}
+	document, err := parsePolicy(aws.ToString(endpoint.PolicyDocument))
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to read policy of VPC endpoint %q: %w", aws.ToString(endpoint.VpcEndpointId), err,
+		)
+	}
+	return document.allowedPrincipals(), nil
+}
+
 func vpcEndpointsToVpcEndpoint(r *ec2.DescribeVpcEndpointsOutput) iter.Seq[types.VpcEndpoint] {
 	return slices.Values(r.VpcEndpoints)
 }
 
 func findVpcEndpoint(needle string, endpoint types.VpcEndpoint) bool {
-	if check(needle, endpoint.OwnerId, endpoint.ServiceName) {
+	if check(needle, endpoint.VpcEndpointId, endpoint.OwnerId, endpoint.ServiceName) ||
+		ec2TagNeedleMatches(needle, endpoint.Tags) {
 		return true
 	}
 	for _, entry := range endpoint.DnsEntries {
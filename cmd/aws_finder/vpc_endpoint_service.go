@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"iter"
 	"slices"
 	"strings"
@@ -15,28 +16,50 @@ import (
 )
 
 func vpcEndpointServiceCmd() *cobra.Command {
-	return &cobra.Command{
+	var owned bool
+	cmd := &cobra.Command{
 		Use:   "vpc_endpoint_service [needle]",
 		Short: "Find a VPC endpoint service by the given service name",
-		Args:  cobra.ExactArgs(1),
+		Args:  cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if !owned && len(args) == 0 {
+				return errors.New("a needle is required unless finding owned services")
+			}
+			var needle string
+			if len(args) == 1 {
+				needle = args[0]
+			}
+			tags := tagFiltersFromContext(cmd.Context())
 			return finder.SearchPerRegion(
 				cmd.Context(),
 				func(ctx context.Context, conf aws.Config) error {
-					return findVpcEndpointService(ctx, args[0], ec2.NewFromConfig(conf))
+					if owned {
+						return findOwnedVpcEndpointServices(ctx, needle, tags, ec2.NewFromConfig(conf))
+					}
+					return findVpcEndpointService(ctx, needle, tags, ec2.NewFromConfig(conf))
 				})
 		},
 	}
+	cmd.Flags().BoolVar(
+		&owned,
+		"owned",
+		false,
+		"Only find services owned by the account, showing the accounts allowed to connect and the connected endpoints",
+	)
+	return cmd
 }
 
 func findVpcEndpointService(
-	ctx context.Context, needle string, client describeVpcEndpointServicesClient,
+	ctx context.Context, needle string, tags []tagFilter, client describeVpcEndpointServicesClient,
 ) error {
-	pages := newDescribeVpcEndpointServicesPaginator(client, nil)
+	filters := ec2NeedleFilters(needle, ec2EndpointServiceNameFilter, ec2TagFilter)
+	pages := newDescribeVpcEndpointServicesPaginator(client, &ec2.DescribeVpcEndpointServicesInput{
+		Filters: append(ec2TagFilters(tags), filters...),
+	})
 
 	seq := paginatorToSeq(ctx, pages, vpcEndpointServicesToServiceDetail)
 	seq = filter2(func(svc types.ServiceDetail, err error) bool {
-		return err != nil || strings.Contains(*svc.ServiceName, needle)
+		return err != nil || len(filters) != 0 || strings.Contains(aws.ToString(svc.ServiceName), needle)
 	}, seq)
 
 	for svc, err := range seq {
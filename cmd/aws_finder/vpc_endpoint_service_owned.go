@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+// findOwnedVpcEndpointServices reports the accounts allowed to connect to each endpoint service owned by the account
+// and the endpoints connected to it.
+func findOwnedVpcEndpointServices(
+	ctx context.Context, needle string, tags []tagFilter, client vpcEndpointServiceOwner,
+) error {
+	for svc, err := range ownedVpcEndpointServices(ctx, needle, tags, client) {
+		if err != nil {
+			return err
+		}
+
+		principals, err := vpcEndpointServicePrincipals(ctx, client, aws.ToString(svc.ServiceId))
+		if err != nil {
+			return err
+		}
+		log.Logger(ctx).InfoContext(
+			ctx,
+			aws.ToString(svc.ServiceName),
+			slog.String("id", aws.ToString(svc.ServiceId)),
+			slog.String("state", string(svc.ServiceState)),
+			slog.Bool("acceptance-required", aws.ToBool(svc.AcceptanceRequired)),
+			slog.String("allowed-principals", strings.Join(principals, ",")),
+		)
+
+		for connection, err := range vpcEndpointServiceConnections(ctx, client, aws.ToString(svc.ServiceId)) {
+			if err != nil {
+				return fmt.Errorf(
+					"failed to list connections to endpoint service %q: %w", aws.ToString(svc.ServiceId), err,
+				)
+			}
+			log.Logger(ctx).InfoContext(
+				ctx,
+				aws.ToString(svc.ServiceName),
+				slog.String("endpoint", aws.ToString(connection.VpcEndpointId)),
+				slog.String("owner", aws.ToString(connection.VpcEndpointOwner)),
+				slog.String("state", string(connection.VpcEndpointState)),
+			)
+		}
+	}
+
+	return nil
+}
+
+// ownedVpcEndpointServices returns the endpoint services owned by the account matching the needle.
+func ownedVpcEndpointServices(
+	ctx context.Context, needle string, tags []tagFilter, client ec2.DescribeVpcEndpointServiceConfigurationsAPIClient,
+) iter.Seq2[types.ServiceConfiguration, error] {
+	filters := ec2NeedleFilters(
+		needle, ec2EndpointServiceNameFilter, ec2IDFilter("service-id", "vpce-svc"), ec2TagFilter,
+	)
+	pages := ec2.NewDescribeVpcEndpointServiceConfigurationsPaginator(
+		client,
+		&ec2.DescribeVpcEndpointServiceConfigurationsInput{
+			Filters: append(ec2TagFilters(tags), filters...),
+		},
+	)
+
+	seq := paginatorToSeq(ctx, pages, serviceConfigurationsToServiceConfiguration)
+	return filter2(func(svc types.ServiceConfiguration, err error) bool {
+		return err != nil || len(filters) != 0 || check(needle, svc.ServiceName, svc.ServiceId)
+	}, seq)
+}
+
+func serviceConfigurationsToServiceConfiguration(
+	r *ec2.DescribeVpcEndpointServiceConfigurationsOutput,
+) iter.Seq[types.ServiceConfiguration] {
+	return slices.Values(r.ServiceConfigurations)
+}
+
+// vpcEndpointServicePrincipals returns the principals allowed to create endpoints connecting to the service.
+func vpcEndpointServicePrincipals(
+	ctx context.Context, client ec2.DescribeVpcEndpointServicePermissionsAPIClient, serviceID string,
+) ([]string, error) {
+	pages := ec2.NewDescribeVpcEndpointServicePermissionsPaginator(
+		client,
+		&ec2.DescribeVpcEndpointServicePermissionsInput{ServiceId: aws.String(serviceID)},
+	)
+
+	var principals []string
+	for principal, err := range paginatorToSeq(ctx, pages, servicePermissionsToAllowedPrincipal) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to list permissions of endpoint service %q: %w", serviceID, err)
+		}
+		principals = append(principals, aws.ToString(principal.Principal))
+	}
+	return principals, nil
+}
+
+func servicePermissionsToAllowedPrincipal(
+	r *ec2.DescribeVpcEndpointServicePermissionsOutput,
+) iter.Seq[types.AllowedPrincipal] {
+	return slices.Values(r.AllowedPrincipals)
+}
+
+// vpcEndpointServiceConnections returns the endpoints, in any account, connected or requesting to connect to the
+// service.
+func vpcEndpointServiceConnections(
+	ctx context.Context, client ec2.DescribeVpcEndpointConnectionsAPIClient, serviceID string,
+) iter.Seq2[types.VpcEndpointConnection, error] {
+	pages := ec2.NewDescribeVpcEndpointConnectionsPaginator(client, &ec2.DescribeVpcEndpointConnectionsInput{
+		Filters: []types.Filter{{Name: aws.String("service-id"), Values: []string{serviceID}}},
+	})
+	return paginatorToSeq(ctx, pages, connectionsToVpcEndpointConnection)
+}
+
+func connectionsToVpcEndpointConnection(
+	r *ec2.DescribeVpcEndpointConnectionsOutput,
+) iter.Seq[types.VpcEndpointConnection] {
+	return slices.Values(r.VpcEndpointConnections)
+}
+
+type vpcEndpointServiceOwner interface {
+	ec2.DescribeVpcEndpointServiceConfigurationsAPIClient
+	ec2.DescribeVpcEndpointServicePermissionsAPIClient
+	ec2.DescribeVpcEndpointConnectionsAPIClient
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wjam/aws_finder/internal/log"
+)
+
+func TestFindOwnedVpcEndpointServices(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	require.NoError(t, findOwnedVpcEndpointServices(ctx, "payments", nil, &ownedEndpointServices{
+		services: []types.ServiceConfiguration{
+			{
+				ServiceId:   aws.String("vpce-svc-1"),
+				ServiceName: aws.String("com.amazonaws.vpce.eu-west-1.orders"),
+			},
+			{
+				ServiceId:          aws.String("vpce-svc-2"),
+				ServiceName:        aws.String("com.amazonaws.vpce.eu-west-1.payments"),
+				ServiceState:       types.ServiceStateAvailable,
+				AcceptanceRequired: aws.Bool(true),
+			},
+		},
+		principals: map[string][]types.AllowedPrincipal{
+			"vpce-svc-1": {{Principal: aws.String("arn:aws:iam::111111111111:root")}},
+			"vpce-svc-2": {
+				{Principal: aws.String("arn:aws:iam::222222222222:root")},
+				{Principal: aws.String("arn:aws:iam::333333333333:role/app")},
+			},
+		},
+		connections: map[string][]types.VpcEndpointConnection{
+			"vpce-svc-2": {
+				{
+					VpcEndpointId:    aws.String("vpce-a"),
+					VpcEndpointOwner: aws.String("222222222222"),
+					VpcEndpointState: "available",
+				},
+				{
+					VpcEndpointId:    aws.String("vpce-b"),
+					VpcEndpointOwner: aws.String("333333333333"),
+					VpcEndpointState: "pendingAcceptance",
+				},
+			},
+		},
+	}))
+
+	assert.Equal(
+		t,
+		"level=INFO msg=com.amazonaws.vpce.eu-west-1.payments id=vpce-svc-2 state=Available acceptance-required=true "+
+			"allowed-principals=arn:aws:iam::222222222222:root,arn:aws:iam::333333333333:role/app\n"+
+			"level=INFO msg=com.amazonaws.vpce.eu-west-1.payments endpoint=vpce-a owner=222222222222 state=available\n"+
+			"level=INFO msg=com.amazonaws.vpce.eu-west-1.payments endpoint=vpce-b owner=333333333333 "+
+			"state=pendingAcceptance\n",
+		buf.String(),
+	)
+}
+
+func TestFindOwnedVpcEndpointServices_PushedDown(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	id := "vpce-svc-0123456789abcdef0"
+	require.NoError(t, findOwnedVpcEndpointServices(ctx, id, nil, &ownedEndpointServices{
+		filters: []types.Filter{{Name: aws.String("service-id"), Values: []string{id}}},
+		services: []types.ServiceConfiguration{
+			{ServiceId: aws.String(id), ServiceName: aws.String("service")},
+		},
+	}))
+
+	assert.Equal(
+		t,
+		"level=INFO msg=service id="+id+" state=\"\" acceptance-required=false allowed-principals=\"\"\n",
+		buf.String(),
+	)
+}
+
+var _ vpcEndpointServiceOwner = &ownedEndpointServices{}
+
+type ownedEndpointServices struct {
+	filters     []types.Filter
+	services    []types.ServiceConfiguration
+	principals  map[string][]types.AllowedPrincipal
+	connections map[string][]types.VpcEndpointConnection
+}
+
+func (o *ownedEndpointServices) DescribeVpcEndpointServiceConfigurations(
+	ctx context.Context, params *ec2.DescribeVpcEndpointServiceConfigurationsInput, _ ...func(*ec2.Options),
+) (*ec2.DescribeVpcEndpointServiceConfigurationsOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if !reflect.DeepEqual(params.Filters, o.filters) {
+		return nil, errors.New("unexpected filters")
+	}
+	return &ec2.DescribeVpcEndpointServiceConfigurationsOutput{ServiceConfigurations: o.services}, nil
+}
+
+func (o *ownedEndpointServices) DescribeVpcEndpointServicePermissions(
+	ctx context.Context, params *ec2.DescribeVpcEndpointServicePermissionsInput, _ ...func(*ec2.Options),
+) (*ec2.DescribeVpcEndpointServicePermissionsOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	return &ec2.DescribeVpcEndpointServicePermissionsOutput{
+		AllowedPrincipals: o.principals[aws.ToString(params.ServiceId)],
+	}, nil
+}
+
+func (o *ownedEndpointServices) DescribeVpcEndpointConnections(
+	ctx context.Context, params *ec2.DescribeVpcEndpointConnectionsInput, _ ...func(*ec2.Options),
+) (*ec2.DescribeVpcEndpointConnectionsOutput, error) {
+	if ctx == nil {
+		return nil, errors.New("missing context")
+	}
+	if len(params.Filters) != 1 || aws.ToString(params.Filters[0].Name) != "service-id" {
+		return nil, errors.New("unexpected filters")
+	}
+	return &ec2.DescribeVpcEndpointConnectionsOutput{
+		VpcEndpointConnections: o.connections[params.Filters[0].Values[0]],
+	}, nil
+}
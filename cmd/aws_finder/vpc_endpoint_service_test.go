@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"reflect"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -27,7 +28,7 @@ func TestFindVpcEndpointService(t *testing.T) {
 		}),
 	}))
 
-	require.NoError(t, findVpcEndpointService(ctx, "find", &vpcEndpoints{
+	require.NoError(t, findVpcEndpointService(ctx, "find", nil, &vpcEndpoints{
 		data: map[string]ec2.DescribeVpcEndpointServicesOutput{
 			"": {
 				NextToken: aws.String("next-one"),
@@ -63,10 +64,40 @@ func TestFindVpcEndpointService(t *testing.T) {
 	assert.Equal(t, "level=INFO msg=\"one to find\"\n", buf.String())
 }
 
+func TestFindVpcEndpointService_PushedDown(t *testing.T) {
+	var buf bytes.Buffer
+
+	ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+		Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+			Level:       slog.LevelDebug,
+			ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+		}),
+	}))
+
+	name := "com.amazonaws.vpce.eu-west-1.vpce-svc-0123456789abcdef0"
+	require.NoError(t, findVpcEndpointService(ctx, name, nil, &vpcEndpoints{
+		filters: []types.Filter{
+			{Name: aws.String("service-name"), Values: []string{name}},
+		},
+		data: map[string]ec2.DescribeVpcEndpointServicesOutput{
+			"": {
+				ServiceDetails: []types.ServiceDetail{
+					{
+						ServiceName: aws.String(name),
+					},
+				},
+			},
+		},
+	}))
+
+	assert.Equal(t, "level=INFO msg="+name+"\n", buf.String())
+}
+
 var _ describeVpcEndpointServicesClient = &vpcEndpoints{}
 
 type vpcEndpoints struct {
-	data map[string]ec2.DescribeVpcEndpointServicesOutput
+	filters []types.Filter
+	data    map[string]ec2.DescribeVpcEndpointServicesOutput
 }
 
 func (v *vpcEndpoints) DescribeVpcEndpointServices(
@@ -75,6 +106,9 @@ func (v *vpcEndpoints) DescribeVpcEndpointServices(
 	if ctx == nil {
 		return nil, errors.New("missing context")
 	}
+	if !reflect.DeepEqual(params.Filters, v.filters) {
+		return nil, errors.New("unexpected filters")
+	}
 	if data, ok := v.data[aws.ToString(params.NextToken)]; ok {
 		return &data, nil
 	}
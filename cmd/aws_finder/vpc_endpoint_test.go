@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -109,25 +110,183 @@ func TestFindVpcEndpoints(t *testing.T) {
 				}),
 			}))
 
-			err := findVpcEndpoints(ctx, test.needle, &vpcEndpointLister{test.endpoints})
+			query := vpcEndpointQuery{needle: test.needle}
+			err := findVpcEndpoints(ctx, query, &vpcEndpointLister{endpoints: test.endpoints})
 			require.NoError(t, err)
 			assert.Equal(t, fmt.Sprintf("level=INFO msg=%s\n", test.expected), buf.String())
 		})
 	}
 }
 
+func TestFindVpcEndpoints_IDAndTag(t *testing.T) {
+	var tests = []struct {
+		needle   string
+		expected string
+	}{
+		{"vpce-0123456789abcdef0", "level=INFO msg=vpce-0123456789abcdef0\n"},
+		{"team=payments", "level=INFO msg=vpce-0fedcba9876543210\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.needle, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			require.NoError(t, findVpcEndpoints(ctx, vpcEndpointQuery{needle: test.needle}, &vpcEndpointLister{
+				endpoints: [][]types.VpcEndpoint{
+					{
+						{
+							VpcEndpointId: aws.String("vpce-0123456789abcdef0"),
+						},
+						{
+							VpcEndpointId: aws.String("vpce-0fedcba9876543210"),
+							Tags:          []types.Tag{{Key: aws.String("team"), Value: aws.String("payments")}},
+						},
+					},
+				},
+			}))
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestFindVpcEndpoints_Filters(t *testing.T) {
+	endpoints := []types.VpcEndpoint{
+		{
+			VpcEndpointId:   aws.String("vpce-gateway"),
+			VpcId:           aws.String("vpc-1"),
+			VpcEndpointType: types.VpcEndpointTypeGateway,
+			State:           "available",
+			PolicyDocument:  aws.String(`{"Statement":[{"Effect":"Allow","Principal":"*","Action":"*"}]}`),
+		},
+		{
+			VpcEndpointId:   aws.String("vpce-interface"),
+			VpcId:           aws.String("vpc-2"),
+			VpcEndpointType: types.VpcEndpointTypeInterface,
+			State:           "pendingAcceptance",
+			SubnetIds:       []string{"subnet-a", "subnet-b"},
+			Groups: []types.SecurityGroupIdentifier{
+				{GroupId: aws.String("sg-1"), GroupName: aws.String("endpoints")},
+			},
+			PolicyDocument: aws.String(
+				`{"Statement":{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"*"}}`,
+			),
+		},
+	}
+
+	const interfaceEndpoint = "level=INFO msg=vpce-interface vpc=vpc-2 type=Interface state=pendingAcceptance " +
+		"subnets=subnet-a,subnet-b security-groups=sg-1 principals=arn:aws:iam::123456789012:root\n"
+
+	var tests = []struct {
+		name     string
+		query    vpcEndpointQuery
+		filters  []types.Filter
+		expected string
+	}{
+		{
+			"vpc",
+			vpcEndpointQuery{vpcID: "vpc-1"},
+			[]types.Filter{{Name: aws.String("vpc-id"), Values: []string{"vpc-1"}}},
+			"level=INFO msg=vpce-gateway vpc=vpc-1 type=Gateway state=available principals=*\n",
+		},
+		{
+			"type",
+			vpcEndpointQuery{endpointType: "Interface"},
+			[]types.Filter{{Name: aws.String("vpc-endpoint-type"), Values: []string{"Interface"}}},
+			interfaceEndpoint,
+		},
+		{
+			"state",
+			vpcEndpointQuery{state: "PendingAcceptance"},
+			nil,
+			interfaceEndpoint,
+		},
+		{
+			"subnet",
+			vpcEndpointQuery{subnet: "subnet-b"},
+			nil,
+			interfaceEndpoint,
+		},
+		{
+			"security group name",
+			vpcEndpointQuery{securityGroup: "endpoints"},
+			nil,
+			interfaceEndpoint,
+		},
+		{
+			"principal in account",
+			vpcEndpointQuery{principal: "arn:aws:iam::123456789012:role/app"},
+			nil,
+			interfaceEndpoint,
+		},
+		{
+			"any principal",
+			vpcEndpointQuery{principal: "*"},
+			nil,
+			"level=INFO msg=vpce-gateway vpc=vpc-1 type=Gateway state=available principals=*\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			err := findVpcEndpoints(ctx, test.query, &vpcEndpointLister{
+				endpoints: [][]types.VpcEndpoint{endpoints},
+				filters:   test.filters,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestFindVpcEndpoints_InvalidPolicy(t *testing.T) {
+	err := findVpcEndpoints(t.Context(), vpcEndpointQuery{principal: "*"}, &vpcEndpointLister{
+		endpoints: [][]types.VpcEndpoint{
+			{
+				{VpcEndpointId: aws.String("vpce-broken"), PolicyDocument: aws.String("{")},
+			},
+		},
+	})
+	require.ErrorContains(t, err, `failed to read policy of VPC endpoint "vpce-broken"`)
+}
+
+func TestVpcEndpointQuery_Validate(t *testing.T) {
+	require.NoError(t, vpcEndpointQuery{endpointType: "GatewayLoadBalancer", state: "rejected"}.validate())
+	require.EqualError(t, vpcEndpointQuery{endpointType: "gateway"}.validate(), `unknown VPC endpoint type "gateway"`)
+	require.EqualError(t, vpcEndpointQuery{state: "broken"}.validate(), `unknown VPC endpoint state "broken"`)
+}
+
 var _ ec2.DescribeVpcEndpointsAPIClient = &vpcEndpointLister{}
 
 type vpcEndpointLister struct {
 	endpoints [][]types.VpcEndpoint
+	filters   []types.Filter
 }
 
 func (v *vpcEndpointLister) DescribeVpcEndpoints(
-	ctx context.Context, _ *ec2.DescribeVpcEndpointsInput, _ ...func(*ec2.Options),
+	ctx context.Context, input *ec2.DescribeVpcEndpointsInput, _ ...func(*ec2.Options),
 ) (*ec2.DescribeVpcEndpointsOutput, error) {
 	if ctx == nil {
 		return nil, errors.New("missing context")
 	}
+	if !reflect.DeepEqual(input.Filters, v.filters) {
+		return nil, errors.New("unexpected filters")
+	}
 	if len(v.endpoints) == 0 {
 		return nil, errors.New("no more values")
 	}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"reflect"
 	"strconv"
 	"testing"
 
@@ -27,7 +28,7 @@ func TestFindVpc(t *testing.T) {
 		}),
 	}))
 
-	require.NoError(t, findVpc(ctx, "needle", &vpcs{
+	require.NoError(t, findVpc(ctx, "needle", nil, &vpcs{
 		data: [][]types.Vpc{
 			{
 				{
@@ -55,10 +56,58 @@ func TestFindVpc(t *testing.T) {
 	assert.Equal(t, "level=INFO msg=\"one to find\"\n", buf.String())
 }
 
+func TestFindVpc_PushedDown(t *testing.T) {
+	var tests = []struct {
+		needle string
+		filter types.Filter
+	}{
+		{
+			"vpc-0123456789abcdef0",
+			types.Filter{Name: aws.String("vpc-id"), Values: []string{"vpc-0123456789abcdef0"}},
+		},
+		{
+			"10.1.0.0/16",
+			types.Filter{Name: aws.String("cidr"), Values: []string{"10.1.0.0/16"}},
+		},
+		{
+			"env=prod",
+			types.Filter{Name: aws.String("tag:env"), Values: []string{"prod"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.needle, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			ctx := log.ContextWithLogger(t.Context(), slog.New(log.WithAttrsFromContextHandler{
+				Parent: slog.NewTextHandler(io.MultiWriter(&buf, t.Output()), &slog.HandlerOptions{
+					Level:       slog.LevelDebug,
+					ReplaceAttr: log.FilterAttributesFromLog([]string{"time"}),
+				}),
+			}))
+
+			require.NoError(t, findVpc(ctx, test.needle, nil, &vpcs{
+				filters: []types.Filter{test.filter},
+				data: [][]types.Vpc{
+					{
+						{
+							CidrBlock: aws.String("10.1.0.0/16"),
+							VpcId:     aws.String("found"),
+						},
+					},
+				},
+			}))
+
+			assert.Equal(t, "level=INFO msg=found\n", buf.String())
+		})
+	}
+}
+
 var _ ec2.DescribeVpcsAPIClient = &vpcs{}
 
 type vpcs struct {
-	data [][]types.Vpc
+	filters []types.Filter
+	data    [][]types.Vpc
 }
 
 func (v *vpcs) DescribeVpcs(
@@ -67,7 +116,7 @@ func (v *vpcs) DescribeVpcs(
 	if ctx == nil {
 		return nil, errors.New("missing context")
 	}
-	if len(input.Filters) != 0 || len(input.VpcIds) != 0 {
+	if !reflect.DeepEqual(input.Filters, v.filters) || len(input.VpcIds) != 0 {
 		return nil, errors.New("invalid input")
 	}
 